@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/dylanlott/guildmaster/internal/analyzer"
+	"github.com/dylanlott/guildmaster/internal/source"
+)
+
+// runBootstrap prints the leaderboard with bootstrap confidence intervals and
+// statistically tied groups.
+func runBootstrap(args []string) error {
+	fs := flag.NewFlagSet("bootstrap", flag.ExitOnError)
+	src := source.Config{Kind: "csv", Path: "./mtgscores.csv"}
+	src.RegisterFlags(fs)
+	samples := fs.Int("samples", 1000, "number of resampled histories")
+	confidence := fs.Float64("confidence", 0.95, "confidence level of the reported intervals")
+	seed := fs.Uint64("seed", 1, "random seed for reproducible resampling")
+	fs.Parse(args)
+
+	all, err := loadGames(src)
+	if err != nil {
+		return err
+	}
+	var games [][]string
+	for _, g := range all {
+		if len(g.Rankings) >= 2 {
+			games = append(games, g.Rankings)
+		}
+	}
+	intervals, err := analyzer.Bootstrap(analyzer.InitializeElo(), games, analyzer.BootstrapConfig{
+		Samples:    *samples,
+		Confidence: *confidence,
+		Seed:       *seed,
+	})
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Rank\tPlayer\tElo\t%.0f%% Elo\t%.0f%% rank\tTier\n", 100**confidence, 100**confidence)
+	for _, iv := range intervals {
+		fmt.Fprintf(w, "%d\t%s\t%d\t%d-%d\t%d-%d\t%d\n",
+			iv.Rank, iv.Player, iv.Rating, iv.RatingLow, iv.RatingHigh, iv.RankLow, iv.RankHigh, iv.Group)
+	}
+	return w.Flush()
+}
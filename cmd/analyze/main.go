@@ -1,26 +1,40 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 
 	"github.com/dylanlott/guildmaster/internal/analyzer"
+	"github.com/dylanlott/guildmaster/internal/source"
 )
 
 func main() {
-	path := flag.String("path", "./mtgscores.csv", "path to analyze with tracker")
+	cfg := source.Config{Kind: "csv", Path: "./mtgscores.csv"}
+	cfg.RegisterFlags(flag.CommandLine)
 	useTUI := flag.Bool("tui", false, "use terminal UI for displaying rankings")
 	flag.Parse()
 
 	if *useTUI {
-		log.SetOutput(nil)
+		log.SetOutput(io.Discard)
 	}
 
-	elo := analyzer.InitializeElo()
-	scores := make(map[string]int)
-
-	if err := analyzer.ProcessScores(*path, elo, scores); err != nil {
+	src, err := cfg.Open()
+	if err != nil {
+		log.Fatalf("Error opening game source: %v", err)
+	}
+	games, warnings, err := analyzer.LoadGames(context.Background(), src)
+	if err != nil {
+		log.Fatalf("Error processing scores: %v", err)
+	}
+	for _, w := range warnings {
+		log.Printf("warning: %s", w)
+	}
+	analyzer.SortChronologically(games)
+	scores, err := analyzer.RateGames(analyzer.InitializeElo(), games, nil)
+	if err != nil {
 		log.Fatalf("Error processing scores: %v", err)
 	}
 
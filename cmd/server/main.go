@@ -1,48 +1,176 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
+	"github.com/dylanlott/guildmaster/internal/auth"
+	"github.com/dylanlott/guildmaster/internal/schedule"
 	"github.com/dylanlott/guildmaster/internal/scoring"
 	"github.com/dylanlott/guildmaster/internal/server"
+	"github.com/dylanlott/guildmaster/internal/source"
 )
 
 func main() {
 	addr := flag.String("addr", ":8080", "http listen address")
 	staticDir := flag.String("static", "assets", "static assets directory")
+	cfg := source.Config{Kind: "sheets", Path: "./mtgscores.csv"}
+	cfg.RegisterFlags(flag.CommandLine)
+	seatAdjust := flag.Bool("seat-advantage", false, "adjust expected scores for the estimated seat advantage")
+	quorum := flag.Int("quorum", 0, "how many players must confirm a game recorded through the API before it is scored (default more than half)")
+	storeKind := flag.String("store", "memory", "where to keep scores and games: memory or bolt")
+	dbPath := flag.String("db", "guildmaster.db", "database file for -store=bolt; with -leagues each league gets its own file named after it")
+	leaguesFile := flag.String("leagues", "", "JSON file listing the leagues to host, each with its own game source and rating config; replaces the source flags")
+	tokensFile := flag.String("tokens", os.Getenv("GUILDMASTER_TOKENS"), "file of API tokens minted with guildmaster token; when set, changes need a scorekeeper or admin token (env GUILDMASTER_TOKENS)")
+	private := flag.Bool("private", false, "also require a viewer token to read scores and games, closing the landing page to browsers (needs -tokens)")
+	refreshEvery := flag.Duration("refresh-every", 5*time.Minute, "how often to refresh the scores from the game source in the background; 0 turns it off")
+	maxBackoff := flag.Duration("refresh-max-backoff", schedule.DefaultMaxBackoff, "longest wait between background refreshes while the game source keeps failing")
 	flag.Parse()
+	if *refreshEvery < 0 || *maxBackoff < 0 {
+		log.Fatalf("-refresh-every and -refresh-max-backoff can't be negative")
+	}
 
-	store := scoring.NewStore()
-	srv := server.New(store)
+	leagues := []source.League{{ID: "default", Config: cfg, SeatAdvantage: *seatAdjust, Quorum: *quorum}}
+	if *leaguesFile != "" {
+		var err error
+		if leagues, err = source.LoadLeagues(*leaguesFile); err != nil {
+			log.Fatalf("%v", err)
+		}
+	}
 
-	mux := http.NewServeMux()
-	mux.HandleFunc("/api/scores", srv.HandleGetScores)
-	mux.HandleFunc("/api/refresh", srv.HandleRefresh)
-	mux.HandleFunc("/api/games", func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case http.MethodGet:
-			srv.HandleGetGames(w, r)
-		default:
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	var servers []*server.Server
+	for _, l := range leagues {
+		path := *dbPath
+		if *leaguesFile != "" {
+			path = leagueDB(path, l.ID)
 		}
-	})
+		store, err := openStore(*storeKind, path)
+		if err != nil {
+			log.Fatalf("league %s: %v", l.ID, err)
+		}
+		defer store.Close()
+		srv, err := newLeague(l, store)
+		if err != nil {
+			log.Fatalf("league %s: %v", l.ID, err)
+		}
+		if *refreshEvery > 0 {
+			srv.Schedule = &schedule.Scheduler{Interval: *refreshEvery, MaxBackoff: *maxBackoff}
+		}
+		servers = append(servers, srv)
+	}
+	hosted, err := server.NewLeagues(servers...)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	switch {
+	case *tokensFile != "":
+		if hosted.Tokens, err = auth.Open(*tokensFile); err != nil {
+			log.Fatalf("%v", err)
+		}
+		hosted.Private = *private
+	case *private:
+		log.Fatalf("-private needs a -tokens file")
+	default:
+		log.Printf("no -tokens file: anyone can record, edit and void games")
+	}
 
-	// Serve the embedded landing page at / and static assets under /static/
-	mux.HandleFunc("/", srv.HandleLanding)
+	mux := http.NewServeMux()
+	hosted.Register(mux)
+	// static assets under /static/; the landing page is embedded
 	fs := http.FileServer(http.Dir(*staticDir))
 	mux.Handle("/static/", http.StripPrefix("/static/", fs))
 
-	// initial refresh to populate in-memory scores (ignore errors; landing page computes on the fly if needed)
-	if err := srv.RefreshAndPersistScores(); err != nil {
+	// stop on Ctrl-C or SIGTERM, ending the background refreshes and the
+	// requests in flight, including /api/events streams
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// initial refresh to populate the scores; reads serve what the stores hold, so a failure here only leaves them stale
+	if err := hosted.RefreshAll(ctx); err != nil {
 		log.Printf("initial refresh failed: %v", err)
 	}
+	var wg sync.WaitGroup
+	wg.Go(func() { hosted.RunSchedules(ctx) })
 
-	log.Printf("listening on %s, serving static from %s", *addr, *staticDir)
-	if err := http.ListenAndServe(*addr, mux); err != nil {
+	httpServer := &http.Server{Addr: *addr, Handler: mux, BaseContext: func(net.Listener) context.Context { return ctx }}
+	errc := make(chan error, 1)
+	go func() { errc <- httpServer.ListenAndServe() }()
+	log.Printf("listening on %s with %d league(s), serving static from %s", *addr, len(servers), *staticDir)
+	if *refreshEvery > 0 {
+		log.Printf("refreshing scores every %v", *refreshEvery)
+	}
+	select {
+	case err := <-errc:
 		log.Printf("server failed: %v", err)
 		os.Exit(1)
+	case <-ctx.Done():
+	}
+
+	log.Printf("shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		log.Printf("shutdown failed: %v", err)
+	}
+	wg.Wait()
+}
+
+// leagueDB returns the database file of league id when several leagues are
+// hosted: path with the ID before its extension, e.g. data/guildmaster-cube.db.
+func leagueDB(path, id string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-" + id + ext
+}
+
+// openStore opens the store for one league.
+func openStore(kind, path string) (scoring.Storage, error) {
+	switch kind {
+	case "memory":
+		return scoring.NewStore(), nil
+	case "bolt":
+		return scoring.OpenBolt(path)
+	default:
+		return nil, fmt.Errorf("unknown store %q: want memory or bolt", kind)
+	}
+}
+
+// newLeague sets up the server for one league and replays its journal into store.
+func newLeague(l source.League, store scoring.Storage) (*server.Server, error) {
+	src, err := l.Open()
+	if err != nil {
+		return nil, err
+	}
+	srv := server.New(store, src)
+	srv.ID, srv.Name = l.ID, l.Name
+	if l.K > 0 {
+		srv.K = l.K
+	}
+	if l.D > 0 {
+		srv.D = l.D
+	}
+	srv.SeatAdjust, srv.Quorum = l.SeatAdvantage, l.Quorum
+	if srv.Dates, err = l.DateParser(); err != nil {
+		return nil, err
+	}
+
+	// the bolt store keeps the game journal; otherwise it only lasts as long as the process
+	journal, ok := store.(scoring.Journal)
+	if !ok {
+		journal = scoring.NewMemoryJournal()
+	}
+	if err := srv.UseJournal(journal); err != nil {
+		return nil, fmt.Errorf("failed to replay game journal: %w", err)
 	}
+	return srv, nil
 }
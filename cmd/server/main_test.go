@@ -0,0 +1,55 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dylanlott/guildmaster/internal/analyzer"
+)
+
+func TestLeagueDB(t *testing.T) {
+	for path, want := range map[string]string{
+		"guildmaster.db":      "guildmaster-cube.db",
+		"data/guildmaster.db": "data/guildmaster-cube.db",
+		"data.v2/scores":      "data.v2/scores-cube",
+	} {
+		if got := leagueDB(path, "cube"); got != want {
+			t.Errorf("leagueDB(%q) = %q, want %q", path, got, want)
+		}
+	}
+
+	// each league's bolt store is its own file, so their games stay apart
+	dir := t.TempDir()
+	db := filepath.Join(dir, "guildmaster.db")
+	for _, id := range []string{"pod", "cube"} {
+		store, err := openStore("bolt", leagueDB(db, id))
+		if err != nil {
+			t.Fatal(err)
+		}
+		games := []analyzer.Game{{ID: id, Date: "3/1/2024", Rankings: []string{"A", "B"}}}
+		if err := store.ReplaceGames(games); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, id := range []string{"pod", "cube"} {
+		store, err := openStore("bolt", leagueDB(db, id))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if games := store.Games(); len(games) != 1 || games[0].ID != id {
+			t.Errorf("league %s's store holds %+v", id, games)
+		}
+		store.Close()
+	}
+	if _, err := os.Stat(db); !os.IsNotExist(err) {
+		t.Errorf("the unsuffixed database was created: %v", err)
+	}
+
+	if _, err := openStore("postgres", db); err == nil {
+		t.Error("opened an unknown store")
+	}
+}
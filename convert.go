@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/dylanlott/guildmaster/internal/analyzer"
+	"github.com/dylanlott/guildmaster/internal/source"
+)
+
+// runConvert writes the game log from any source in another format, e.g. to
+// move history from the CSV file or the sheet into a JSON Lines file.
+func runConvert(args []string) error {
+	fs := flag.NewFlagSet("convert", flag.ExitOnError)
+	src := source.Config{Kind: "csv", Path: "./mtgscores.csv"}
+	src.RegisterFlags(fs)
+	to := fs.String("to", "jsonl", "output format: jsonl or csv")
+	out := fs.String("out", "", "output file (default stdout)")
+	fs.Parse(args)
+
+	var write func(io.Writer, []analyzer.Game) error
+	switch *to {
+	case "jsonl":
+		write = analyzer.WriteJSONL
+	case "csv":
+		write = analyzer.WriteCSV
+	default:
+		return fmt.Errorf("unknown output format %q: want jsonl or csv", *to)
+	}
+
+	games, err := loadGames(src)
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		return write(os.Stdout, games)
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err := write(f, games); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
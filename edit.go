@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dylanlott/guildmaster/internal/analyzer"
+	"github.com/dylanlott/guildmaster/internal/scoring"
+)
+
+// apiClient calls a running server's API.
+type apiClient struct {
+	server, league, token *string
+}
+
+// apiFlags adds the -server, -league and -token flags used by commands that
+// go through a running server's API.
+func apiFlags(fs *flag.FlagSet) apiClient {
+	def := os.Getenv("GUILDMASTER_SERVER")
+	if def == "" {
+		def = "http://localhost:8080"
+	}
+	return apiClient{
+		server: fs.String("server", def, "URL of the guildmaster server (env GUILDMASTER_SERVER)"),
+		league: fs.String("league", "", "league to change when the server hosts several (default: its first league)"),
+		token:  fs.String("token", os.Getenv("GUILDMASTER_TOKEN"), "API token, see guildmaster token (env GUILDMASTER_TOKEN)"),
+	}
+}
+
+// request builds a request for an API path, e.g. "/games/12".
+func (c apiClient) request(method, path string, body []byte) (*http.Request, error) {
+	base := strings.TrimSuffix(*c.server, "/") + "/api"
+	if *c.league != "" {
+		base += "/leagues/" + url.PathEscape(*c.league)
+	}
+	req, err := http.NewRequest(method, base+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if *c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+*c.token)
+	}
+	return req, nil
+}
+
+// runEdit corrects a game through the server, which re-rates every game
+// after it. Players, if given, are the corrected finishing order.
+func runEdit(args []string) error {
+	fs := flag.NewFlagSet("edit", flag.ExitOnError)
+	api := apiFlags(fs)
+	date := fs.String("date", "", "corrected date of the game; moves it in the game order")
+	notes := fs.String("notes", "", "corrected notes")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: guildmaster edit [flags] game-id [winner second ...]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return fmt.Errorf("missing game ID")
+	}
+
+	edit := map[string]any{}
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "date":
+			edit["date"] = *date
+		case "notes":
+			edit["notes"] = *notes
+		}
+	})
+	if players := fs.Args()[1:]; len(players) > 0 {
+		if len(players) < 2 {
+			return fmt.Errorf("need at least 2 players in finishing order")
+		}
+		edit["rankings"] = players
+	}
+	if len(edit) == 0 {
+		return fmt.Errorf("nothing to change: give -date, -notes or the players in finishing order")
+	}
+	body, err := json.Marshal(edit)
+	if err != nil {
+		return err
+	}
+	return changeGame(api, http.MethodPut, fs.Arg(0), "", body)
+}
+
+// runVoid voids a game through the server, which re-rates every game after it.
+func runVoid(args []string) error {
+	fs := flag.NewFlagSet("void", flag.ExitOnError)
+	api := apiFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: guildmaster void [flags] game-id")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("need exactly one game ID")
+	}
+	return changeGame(api, http.MethodDelete, fs.Arg(0), "", nil)
+}
+
+// runConfirm confirms or rejects a game recorded through the server, as the
+// player or scorekeeper the -token was minted for.
+func runConfirm(args []string) error {
+	fs := flag.NewFlagSet("confirm", flag.ExitOnError)
+	api := apiFlags(fs)
+	reject := fs.Bool("reject", false, "dispute the game instead")
+	player := fs.String("player", "", "player confirming, when the server doesn't check tokens")
+	approve := fs.Bool("approve", false, "confirm or reject it as a scorekeeper, for its players")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: guildmaster confirm [flags] game-id")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("need exactly one game ID")
+	}
+	body, err := json.Marshal(map[string]any{"player": *player, "approve": *approve})
+	if err != nil {
+		return err
+	}
+	action := "/confirm"
+	if *reject {
+		action = "/reject"
+	}
+	return changeGame(api, http.MethodPost, fs.Arg(0), action, body)
+}
+
+// changeGame sends an edit to /api/games/{id}, or one of its actions such as
+// "/confirm", and prints the ratings it changed.
+func changeGame(api apiClient, method, id, action string, body []byte) error {
+	req, err := api.request(method, "/games/"+url.PathEscape(id)+action, body)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	var result struct {
+		Game    *analyzer.Game         `json:"game"`
+		Changes []scoring.RatingChange `json:"changes"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+
+	if g := result.Game; g != nil {
+		fmt.Printf("game %s on %s: %s\n", id, g.Date, strings.Join(g.Rankings, ", "))
+		switch g.Status {
+		case analyzer.Pending:
+			fmt.Printf("pending confirmation, confirmed by %s\n", strings.Join(g.ConfirmedBy, ", "))
+		case analyzer.Rejected:
+			fmt.Printf("rejected by %s\n", g.RejectedBy)
+		}
+	} else {
+		fmt.Printf("voided game %s\n", id)
+	}
+	if len(result.Changes) == 0 {
+		fmt.Println("no ratings changed")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Player\tBefore\tAfter\tChange")
+	for _, c := range result.Changes {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%+d\n", c.Player, c.Before, c.After, c.Delta())
+	}
+	return w.Flush()
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// gameServer serves /api/games/{id}, recording the last request it got, and
+// answers with status and resp.
+func gameServer(t *testing.T, status int, resp string) (*httptest.Server, *http.Request, *[]byte) {
+	t.Helper()
+	var (
+		got  http.Request
+		body []byte
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = *r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+		io.WriteString(w, resp)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &got, &body
+}
+
+func TestRunEdit(t *testing.T) {
+	srv, req, body := gameServer(t, http.StatusOK,
+		`{"game": {"id": "7", "date": "3/1/2024", "rankings": ["C", "B", "A"]}, "changes": [{"player": "C", "before": 1500, "after": 1516}]}`)
+	if err := runEdit([]string{"-server", srv.URL + "/", "-notes", "", "7", "C", "B", "A"}); err != nil {
+		t.Fatal(err)
+	}
+	if req.Method != http.MethodPut || req.URL.Path != "/api/games/7" {
+		t.Errorf("sent %s %s, want PUT /api/games/7", req.Method, req.URL.Path)
+	}
+	var edit map[string]any
+	if err := json.Unmarshal(*body, &edit); err != nil {
+		t.Fatal(err)
+	}
+	if len(edit) != 2 || edit["notes"] != "" || edit["rankings"] == nil {
+		t.Errorf("sent edit %v, want the notes cleared and the new rankings", edit)
+	}
+
+	for _, args := range [][]string{
+		{"-server", srv.URL},
+		{"-server", srv.URL, "7"},
+		{"-server", srv.URL, "7", "A"},
+	} {
+		if err := runEdit(args); err == nil {
+			t.Errorf("edit %v: no error", args[2:])
+		}
+	}
+}
+
+func TestRunVoid(t *testing.T) {
+	srv, req, _ := gameServer(t, http.StatusOK, `{"changes": []}`)
+	if err := runVoid([]string{"-server", srv.URL, "a b"}); err != nil {
+		t.Fatal(err)
+	}
+	if req.Method != http.MethodDelete || req.URL.EscapedPath() != "/api/games/a%20b" {
+		t.Errorf("sent %s %s, want DELETE /api/games/a%%20b", req.Method, req.URL.EscapedPath())
+	}
+
+	srv, _, _ = gameServer(t, http.StatusNotFound, "game 9: no such game\n")
+	err := runVoid([]string{"-server", srv.URL, "9"})
+	if err == nil || !strings.Contains(err.Error(), "no such game") {
+		t.Errorf("voiding an unknown game: %v, want the server's error", err)
+	}
+}
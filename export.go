@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/dylanlott/guildmaster/internal/analyzer"
+	"github.com/dylanlott/guildmaster/internal/export"
+	"github.com/dylanlott/guildmaster/internal/source"
+)
+
+// runExport writes rankings, game history or head-to-head records as a table
+// in the chosen format.
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	src := source.Config{Kind: "csv", Path: "./mtgscores.csv"}
+	src.RegisterFlags(fs)
+	table := fs.String("table", "rankings", "table to export: rankings, history or head-to-head")
+	format := fs.String("format", "csv", "output format: "+export.Formats)
+	out := fs.String("out", "", "output file (default stdout)")
+	fs.Parse(args)
+
+	f, err := export.ParseFormat(*format)
+	if err != nil {
+		return err
+	}
+	games, err := loadGames(src)
+	if err != nil {
+		return err
+	}
+	var t export.Table
+	switch *table {
+	case "rankings":
+		scores, err := analyzer.RateGames(analyzer.InitializeElo(), games, nil)
+		if err != nil {
+			return err
+		}
+		t = export.Rankings(scores)
+	case "history":
+		t = export.History(games)
+	case "head-to-head":
+		t = export.HeadToHead(games)
+	default:
+		return fmt.Errorf("unknown table %q: want rankings, history or head-to-head", *table)
+	}
+
+	if *out == "" {
+		return export.Write(os.Stdout, t, f)
+	}
+	file, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err := export.Write(file, t, f); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
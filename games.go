@@ -0,0 +1,30 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dylanlott/guildmaster/internal/source"
+)
+
+// runGames lists the games in the log, oldest first, with their notes.
+func runGames(args []string) error {
+	fs := flag.NewFlagSet("games", flag.ExitOnError)
+	src := source.Config{Kind: "csv", Path: "./mtgscores.csv"}
+	src.RegisterFlags(fs)
+	fs.Parse(args)
+
+	games, err := loadGames(src)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Date\tGame\tPlayers\tNotes")
+	for _, g := range games {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", g.Date, g.ID, strings.Join(g.Rankings, ", "), g.Notes)
+	}
+	return w.Flush()
+}
@@ -7,6 +7,7 @@ require (
 	github.com/charmbracelet/bubbletea v1.3.4
 	github.com/charmbracelet/lipgloss v1.0.0
 	github.com/kortemy/elo-go v0.0.0-20190919090953-f9d3a99fd7b7
+	go.etcd.io/bbolt v1.3.10
 	google.golang.org/api v0.134.0
 )
 
@@ -1,11 +1,9 @@
 package analyzer
 
 import (
-	"encoding/csv"
+	"context"
 	"fmt"
-	"io"
 	"math"
-	"os"
 	"sort"
 	"strings"
 
@@ -32,34 +30,32 @@ func InitializeElo() *elogo.Elo {
 // ProcessScores reads the CSV at path and scores every game into the provided scores map.
 // The map is mutated with absolute ratings.
 func ProcessScores(path string, elo *elogo.Elo, scores map[string]int) error {
-	file, err := os.Open(path)
+	games, err := ReadGames(path)
 	if err != nil {
-		return fmt.Errorf("failed to open scores file: %w", err)
+		return err
 	}
-	defer file.Close()
-
-	reader := csv.NewReader(file)
-	for {
-		record, err := reader.Read()
-		if err != nil {
-			if err == io.EOF {
-				break
-			}
-			return fmt.Errorf("error reading record: %w", err)
-		}
-
-		if len(record) < 3 {
-			continue
+	for _, game := range games {
+		if err := ScoreGame(elo, scores, game); err != nil {
+			return fmt.Errorf("failed to score game: %w", err)
 		}
+	}
+	return nil
+}
 
-		game := ParseGame(record[2:])
-		if len(game) >= 2 {
-			if err := ScoreGame(elo, scores, game); err != nil {
-				return fmt.Errorf("failed to score game: %w", err)
-			}
+// ReadGames reads the CSV at path and returns every scorable game in
+// chronological order, each as a slice of player names (winner first).
+func ReadGames(path string) ([][]string, error) {
+	all, err := CSVSource{Path: path}.Games(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	var games [][]string
+	for _, g := range all {
+		if len(g.Rankings) >= 2 {
+			games = append(games, g.Rankings)
 		}
 	}
-	return nil
+	return games, nil
 }
 
 // ParseGame turns CSV columns into an ordered slice of player names (winner first).
@@ -78,6 +74,12 @@ func ParseGame(players []string) []string {
 // ScoreGame computes and applies Elo changes for a single game (winner first).
 // scores map is mutated to hold absolute ratings (defaulting to DefaultStartingScore when absent).
 func ScoreGame(elo *elogo.Elo, scores map[string]int, game []string) error {
+	return scoreGame(elo, scores, game, nil)
+}
+
+// scoreGame implements ScoreGame. offsets, when non-nil, holds a rating bonus per
+// player (same order as game) that is added only when computing expected scores.
+func scoreGame(elo *elogo.Elo, scores map[string]int, game []string, offsets []float64) error {
 	numPlayers := len(game)
 	if numPlayers < 2 {
 		return fmt.Errorf("invalid game: need at least 2 players, got %d", numPlayers)
@@ -99,10 +101,12 @@ func ScoreGame(elo *elogo.Elo, scores map[string]int, game []string) error {
 	D := float64(elo.D)
 	for i := 0; i < numPlayers; i++ {
 		for j := i + 1; j < numPlayers; j++ {
-			RA := ratings[i]
-			RB := ratings[j]
-
-			EA := 1.0 / (1.0 + math.Pow(10, (RB-RA)/D))
+			RA, RB := ratings[i], ratings[j]
+			if offsets != nil {
+				RA += offsets[i]
+				RB += offsets[j]
+			}
+			EA := ExpectedScore(RA, RB, D)
 
 			deltaA := K * (1.0 - EA)
 			deltaB := -deltaA
@@ -121,6 +125,12 @@ func ScoreGame(elo *elogo.Elo, scores map[string]int, game []string) error {
 	return nil
 }
 
+// ExpectedScore returns the Elo expected score of a player rated ra against a
+// player rated rb, i.e. the probability that A finishes ahead of B.
+func ExpectedScore(ra, rb, d float64) float64 {
+	return 1.0 / (1.0 + math.Pow(10, (rb-ra)/d))
+}
+
 // CalculateFinalScores converts the scores map into a sorted slice of FinalScore
 // ordered by Elo desc then player name asc.
 func CalculateFinalScores(scores map[string]int) []FinalScore {
@@ -0,0 +1,101 @@
+package analyzer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCSVSourceGames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "games.csv")
+	data := ",1/20/2020,Marshall,Colton,,,Notes after the players\n" +
+		"g3,2020-01-05,Colton,Marshall,,,\n" +
+		"g2,2/13/2020,Dylan,,,,\n" +
+		"g4,someday,Dylan,Colton,,,\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	games, warnings, err := CSVSource{Path: path}.GamesWithWarnings(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var ids []string
+	for _, g := range games {
+		ids = append(ids, g.ID)
+	}
+	// g3 is dated first; g4 has no usable date and stays after g2
+	if want := []string{"g3", "1", "g2", "g4"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("expected order %v, got %v", want, ids)
+	}
+	if games[1].Notes != "Notes after the players" || !games[1].TrailingNotes || len(games[1].Rankings) != 2 {
+		t.Fatalf("unexpected game: %+v", games[1])
+	}
+	if len(warnings) != 1 || warnings[0].Line != 4 || warnings[0].GameID != "g4" {
+		t.Fatalf("expected a warning for line 4, got %v", warnings)
+	}
+}
+
+func TestCSVSourceHeader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "games.csv")
+	data := "id,date,player1,player2,player3,notes,turns,turn_order,eliminations\n" +
+		"a,2022-01-01,PlayerA,,PlayerB,\"Dylan, who went first, lost\",9,\"PlayerB, PlayerA\",PlayerA>PlayerB\n" +
+		"b,2022-01-08,PlayerB,PlayerA,,,x,,\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	games, warnings, err := CSVSource{Path: path}.GamesWithWarnings(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(games) != 2 {
+		t.Fatalf("expected 2 games, got %d", len(games))
+	}
+	g := games[0]
+	if !reflect.DeepEqual(g.Rankings, []string{"PlayerA", "PlayerB"}) || g.Notes != "Dylan, who went first, lost" || g.TrailingNotes {
+		t.Fatalf("unexpected game: %+v", g)
+	}
+	if g.Turns != 9 || len(g.TurnOrder) != 2 || len(g.Eliminations) != 1 {
+		t.Fatalf("metadata not parsed: %+v", g)
+	}
+	if len(warnings) != 1 || warnings[0].GameID != "b" {
+		t.Fatalf("expected a warning for the bad turn count, got %v", warnings)
+	}
+
+	bad := filepath.Join(t.TempDir(), "bad.csv")
+	if err := os.WriteFile(bad, []byte("id,date,winner\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (CSVSource{Path: bad}).Games(context.Background()); err == nil {
+		t.Fatalf("expected an error for an unknown header column")
+	}
+}
+
+func TestCSVSourceAppendGame(t *testing.T) {
+	for name, data := range map[string]string{
+		"header": "id,date,player1,player2,player3,notes,turns\n1,1/1/2024,A,B,,,7",
+		"legacy": ",1/1/2024,A,B\n",
+	} {
+		path := filepath.Join(t.TempDir(), "games.csv")
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		src := CSVSource{Path: path}
+		g := Game{Date: "1/2/2024", Rankings: []string{"C", "A", "B"}, Notes: "first win"}
+		if err := src.AppendGame(context.Background(), g); err != nil {
+			t.Fatalf("%s: AppendGame: %v", name, err)
+		}
+		games, warnings, err := src.GamesWithWarnings(context.Background())
+		if err != nil || len(warnings) != 0 || len(games) != 2 {
+			t.Fatalf("%s: expected 2 games, got %d, %v, %v", name, len(games), warnings, err)
+		}
+		got := games[1]
+		if strings.Join(got.Rankings, ",") != "C,A,B" || got.Notes != "first win" || got.Date != "1/2/2024" {
+			t.Fatalf("%s: appended game read back as %+v", name, got)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package analyzer
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+
+	elogo "github.com/kortemy/elo-go"
+)
+
+// BootstrapConfig controls bootstrap resampling of the game history.
+type BootstrapConfig struct {
+	// Samples is the number of resampled histories to rate.
+	Samples int
+	// Confidence is the width of the reported intervals, e.g. 0.95.
+	Confidence float64
+	// Seed makes the resampling reproducible.
+	Seed uint64
+}
+
+// RatingInterval is a player's rating and rank with bootstrap confidence intervals.
+type RatingInterval struct {
+	Player     string
+	Rating     int // rating from the full history
+	Rank       int // rank from the full history (1-based)
+	RatingLow  int
+	RatingHigh int
+	RankLow    int
+	RankHigh   int
+	// Group numbers statistically tied players: consecutive players whose rating
+	// interval overlaps the interval of the first player in the group share a group.
+	Group int
+}
+
+// Bootstrap rates many resampled versions of games and reports confidence
+// intervals for every player's final rating and rank. Each resample draws
+// len(games) games with replacement and replays them in their original
+// chronological order. Results follow the full-history leaderboard order.
+func Bootstrap(elo *elogo.Elo, games [][]string, cfg BootstrapConfig) ([]RatingInterval, error) {
+	if cfg.Samples <= 0 {
+		return nil, errors.New("samples must be positive")
+	}
+	if cfg.Confidence <= 0 || cfg.Confidence >= 1 {
+		return nil, fmt.Errorf("invalid confidence %v: must be between 0 and 1", cfg.Confidence)
+	}
+	if len(games) == 0 {
+		return nil, errors.New("no games to resample")
+	}
+
+	full := make(map[string]int)
+	for _, game := range games {
+		if err := ScoreGame(elo, full, game); err != nil {
+			return nil, fmt.Errorf("failed to score game: %w", err)
+		}
+	}
+	leaderboard := CalculateFinalScores(full)
+
+	index := make(map[string]int, len(leaderboard))
+	for i, fs := range leaderboard {
+		index[fs.Player] = i
+	}
+	ratings := make([][]int, len(leaderboard))
+	ranks := make([][]int, len(leaderboard))
+
+	rng := rand.New(rand.NewPCG(cfg.Seed, cfg.Seed))
+	picks := make([]int, len(games))
+	for s := 0; s < cfg.Samples; s++ {
+		for i := range picks {
+			picks[i] = rng.IntN(len(games))
+		}
+		sort.Ints(picks)
+
+		// Players missing from a resample keep the starting score so every
+		// player is ranked in every sample.
+		scores := make(map[string]int, len(leaderboard))
+		for name := range full {
+			scores[name] = DefaultStartingScore
+		}
+		for _, i := range picks {
+			if err := ScoreGame(elo, scores, games[i]); err != nil {
+				return nil, fmt.Errorf("failed to score game: %w", err)
+			}
+		}
+		for rank, fs := range CalculateFinalScores(scores) {
+			i := index[fs.Player]
+			ratings[i] = append(ratings[i], fs.EloScore)
+			ranks[i] = append(ranks[i], rank+1)
+		}
+	}
+
+	alpha := (1 - cfg.Confidence) / 2
+	out := make([]RatingInterval, len(leaderboard))
+	for i, fs := range leaderboard {
+		out[i] = RatingInterval{
+			Player:     fs.Player,
+			Rating:     fs.EloScore,
+			Rank:       i + 1,
+			RatingLow:  percentile(ratings[i], alpha),
+			RatingHigh: percentile(ratings[i], 1-alpha),
+			RankLow:    percentile(ranks[i], alpha),
+			RankHigh:   percentile(ranks[i], 1-alpha),
+		}
+	}
+
+	group, leader := 1, 0
+	for i := range out {
+		if i > 0 && out[i].RatingHigh < out[leader].RatingLow {
+			group++
+			leader = i
+		}
+		out[i].Group = group
+	}
+	return out, nil
+}
+
+// percentile returns the nearest-rank percentile q (0..1) of values. values is sorted in place.
+func percentile(values []int, q float64) int {
+	if len(values) == 0 {
+		return 0
+	}
+	sort.Ints(values)
+	i := int(math.Ceil(q*float64(len(values)))) - 1
+	return values[max(0, min(i, len(values)-1))]
+}
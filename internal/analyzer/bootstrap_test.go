@@ -0,0 +1,36 @@
+package analyzer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBootstrapIntervals(t *testing.T) {
+	var games [][]string
+	for i := 0; i < 20; i++ {
+		games = append(games, []string{"A", "B", "C"})
+	}
+	cfg := BootstrapConfig{Samples: 200, Confidence: 0.9, Seed: 3}
+
+	first, err := Bootstrap(InitializeElo(), games, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, _ := Bootstrap(InitializeElo(), games, cfg)
+	if !reflect.DeepEqual(first, second) {
+		t.Fatalf("same seed produced different intervals")
+	}
+
+	for _, iv := range first {
+		if iv.RatingLow > iv.RatingHigh || iv.RankLow > iv.RankHigh {
+			t.Fatalf("%s: inverted interval %+v", iv.Player, iv)
+		}
+	}
+	// A always beats B who always beats C, so nobody is statistically tied.
+	if first[0].Player != "A" || first[0].RankHigh != 1 {
+		t.Fatalf("expected A to be first in every resample, got %+v", first[0])
+	}
+	if first[2].Group != 3 {
+		t.Fatalf("expected three separate groups, got %+v", first)
+	}
+}
@@ -0,0 +1,366 @@
+package analyzer
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// CSVSource reads games from a CSV file.
+//
+// A file whose first row names its columns (see CSVColumns) is read by
+// header. Otherwise the legacy layout is assumed: an identifier column
+// (usually empty), the date, then players in finishing order up to the first
+// empty cell, with any text after the players kept as the game's notes.
+type CSVSource struct {
+	Path string
+	// Dates parses the date column.
+	Dates DateParser
+	// Strict drops games whose date can't be parsed instead of keeping them.
+	Strict bool
+}
+
+// CSVColumns lists the header names understood by CSVSource. Player columns
+// are any columns named "player" optionally followed by a number, e.g.
+// player1, player2, ...; they are read left to right in finishing order.
+var CSVColumns = []string{"id", "date", "player", "notes", "table_zap", "draw_game", "turns", "turn_order", "eliminations"}
+
+// csvSchema maps header names to column indexes; -1 marks an absent column.
+type csvSchema struct {
+	id, date, notes, zap, draw, turns, turnOrder, elims int
+	players                                             []int
+}
+
+// parseCSVHeader returns the schema described by record, or nil when record is
+// not a header row. A row is a header when one of its cells is "date".
+func parseCSVHeader(record []string) (*csvSchema, error) {
+	isHeader := false
+	for _, cell := range record {
+		if strings.EqualFold(strings.TrimSpace(cell), "date") {
+			isHeader = true
+		}
+	}
+	if !isHeader {
+		return nil, nil
+	}
+
+	s := &csvSchema{id: -1, date: -1, notes: -1, zap: -1, draw: -1, turns: -1, turnOrder: -1, elims: -1}
+	for i, cell := range record {
+		name := strings.ToLower(strings.TrimSpace(cell))
+		var col *int
+		switch name {
+		case "":
+			continue
+		case "id":
+			col = &s.id
+		case "date":
+			col = &s.date
+		case "notes":
+			col = &s.notes
+		case "table_zap":
+			col = &s.zap
+		case "draw_game":
+			col = &s.draw
+		case "turns":
+			col = &s.turns
+		case "turn_order":
+			col = &s.turnOrder
+		case "eliminations":
+			col = &s.elims
+		default:
+			if suffix, ok := strings.CutPrefix(name, "player"); ok && (suffix == "" || isDigits(suffix)) {
+				s.players = append(s.players, i)
+				continue
+			}
+			return nil, fmt.Errorf("unknown column %q in header: want one of %s", cell, strings.Join(CSVColumns, ", "))
+		}
+		if *col >= 0 {
+			return nil, fmt.Errorf("duplicate column %q in header", cell)
+		}
+		*col = i
+	}
+	if len(s.players) == 0 {
+		return nil, fmt.Errorf("header has no player columns")
+	}
+	return s, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// game builds a Game from a data row. Problems with the optional metadata
+// columns are returned as messages; the game is still usable.
+func (s *csvSchema) game(record []string) (Game, []string) {
+	cell := func(i int) string {
+		if i < 0 || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+	g := Game{
+		ID:       cell(s.id),
+		Date:     cell(s.date),
+		Notes:    cell(s.notes),
+		TableZap: cell(s.zap),
+		DrawGame: cell(s.draw),
+	}
+	for _, i := range s.players {
+		if name := cell(i); name != "" {
+			g.Rankings = append(g.Rankings, name)
+		}
+	}
+
+	var problems []string
+	turns, err := ParseTurns(cell(s.turns))
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+	g.Turns = turns
+	g.TurnOrder = ParseTurnOrder(cell(s.turnOrder))
+	if err := CheckTurnOrder(g); err != nil {
+		problems = append(problems, err.Error())
+	}
+	elims, err := ParseEliminations(cell(s.elims))
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+	g.Eliminations = elims
+	return g, problems
+}
+
+// legacyGame builds a Game from a row in the headerless layout.
+func legacyGame(record []string) Game {
+	if len(record) < 3 {
+		return Game{}
+	}
+	players := ParseGame(record[2:])
+	g := Game{
+		ID:       strings.TrimSpace(record[0]),
+		Date:     strings.TrimSpace(record[1]),
+		Rankings: players,
+		Notes:    joinNonEmpty(record[2+len(players):]),
+	}
+	g.TrailingNotes = g.Notes != ""
+	return g
+}
+
+// Games implements GameSource, dropping any warnings.
+func (s CSVSource) Games(ctx context.Context) ([]Game, error) {
+	games, _, err := s.GamesWithWarnings(ctx)
+	return games, err
+}
+
+// GamesWithWarnings implements WarningSource. Games are returned in
+// chronological order with file order breaking ties. Games without an
+// identifier are identified by their line number. A game whose date can't be
+// parsed is reported as a warning and, unless Strict is set, keeps its place
+// after the previous game in the file.
+func (s CSVSource) GamesWithWarnings(ctx context.Context) ([]Game, []Warning, error) {
+	file, err := os.Open(s.Path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open scores file: %w", err)
+	}
+	defer file.Close()
+
+	var games []Game
+	var warnings []Warning
+	var schema *csvSchema
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, nil, fmt.Errorf("error reading record: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		if first {
+			schema, err = parseCSVHeader(record)
+			if err != nil {
+				return nil, nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			if schema != nil {
+				continue
+			}
+		}
+
+		var g Game
+		var problems []string
+		if schema != nil {
+			g, problems = schema.game(record)
+		} else {
+			g = legacyGame(record)
+		}
+		if len(g.Rankings) == 0 {
+			continue
+		}
+		g.Line = line
+		if g.ID == "" {
+			g.ID, g.LineID = strconv.Itoa(line), true
+		}
+		for _, p := range problems {
+			warnings = append(warnings, Warning{Line: line, GameID: g.ID, Message: p})
+		}
+
+		ts, err := s.Dates.Parse(g.Date)
+		if err != nil {
+			if s.Strict {
+				warnings = append(warnings, Warning{Line: line, GameID: g.ID, Message: err.Error() + "; game skipped"})
+				continue
+			}
+			warnings = append(warnings, Warning{Line: line, GameID: g.ID, Message: err.Error()})
+			if len(games) > 0 {
+				ts = games[len(games)-1].Timestamp
+			}
+		}
+		g.Timestamp = ts
+		games = append(games, g)
+	}
+	SortChronologically(games)
+	return games, warnings, nil
+}
+
+// joinNonEmpty joins the trimmed, non-empty cells with a single space.
+func joinNonEmpty(cells []string) string {
+	parts := make([]string, 0, len(cells))
+	for _, c := range cells {
+		if c = strings.TrimSpace(c); c != "" {
+			parts = append(parts, c)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// AppendGame implements GameWriter by adding g as a new row at the end of the
+// file. Rows follow the file's header, or the legacy layout when it has none.
+func (s CSVSource) AppendGame(ctx context.Context, g Game) error {
+	if len(g.Rankings) < 2 {
+		return fmt.Errorf("invalid game: need at least 2 players, got %d", len(g.Rankings))
+	}
+	data, err := os.ReadFile(s.Path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read scores file: %w", err)
+	}
+	var record []string
+	header, _ := csv.NewReader(strings.NewReader(string(data))).Read()
+	schema, err := parseCSVHeader(header)
+	if err != nil {
+		return err
+	}
+	if schema != nil {
+		if len(g.Rankings) > len(schema.players) {
+			return fmt.Errorf("game has %d players but the file only has %d player columns", len(g.Rankings), len(schema.players))
+		}
+		record = schema.record(len(header), g)
+	} else {
+		record = append([]string{g.ID, csvDate(g)}, g.Rankings...)
+		if g.Notes != "" {
+			record = append(record, "", g.Notes)
+		}
+	}
+
+	file, err := os.OpenFile(s.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open scores file: %w", err)
+	}
+	defer file.Close()
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		if _, err := file.WriteString("\n"); err != nil {
+			return fmt.Errorf("failed to write scores file: %w", err)
+		}
+	}
+	w := csv.NewWriter(file)
+	w.Write(record)
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("failed to write scores file: %w", err)
+	}
+	return file.Close()
+}
+
+// csvDate returns the date to write for g, formatting its timestamp when it
+// has no date text.
+func csvDate(g Game) string {
+	if g.Date == "" && !g.Timestamp.IsZero() {
+		return g.Timestamp.Format("1/2/2006")
+	}
+	return g.Date
+}
+
+// record lays g out as a row of width cells following the schema.
+func (s *csvSchema) record(width int, g Game) []string {
+	record := make([]string, width)
+	set := func(col int, v string) {
+		if col >= 0 {
+			record[col] = v
+		}
+	}
+	set(s.id, g.ID)
+	set(s.date, csvDate(g))
+	set(s.notes, g.Notes)
+	set(s.zap, g.TableZap)
+	set(s.draw, g.DrawGame)
+	for i, name := range g.Rankings {
+		set(s.players[i], name)
+	}
+	if g.Turns > 0 {
+		set(s.turns, strconv.Itoa(g.Turns))
+	}
+	set(s.turnOrder, FormatTurnOrder(g.TurnOrder))
+	set(s.elims, FormatEliminations(g.Eliminations))
+	return record
+}
+
+// WriteCSV writes games to w as a CSV file with a header row. There is a
+// player column for the largest game, and metadata columns only for fields
+// some game uses.
+func WriteCSV(w io.Writer, games []Game) error {
+	players := 0
+	var zap, draw, turns, order, elims bool
+	for _, g := range games {
+		players = max(players, len(g.Rankings))
+		zap = zap || g.TableZap != ""
+		draw = draw || g.DrawGame != ""
+		turns = turns || g.Turns > 0
+		order = order || len(g.TurnOrder) > 0
+		elims = elims || len(g.Eliminations) > 0
+	}
+	header := []string{"id", "date"}
+	for i := 1; i <= players; i++ {
+		header = append(header, "player"+strconv.Itoa(i))
+	}
+	header = append(header, "notes")
+	for _, col := range []struct {
+		name string
+		used bool
+	}{{"table_zap", zap}, {"draw_game", draw}, {"turns", turns}, {"turn_order", order}, {"eliminations", elims}} {
+		if col.used {
+			header = append(header, col.name)
+		}
+	}
+	schema, err := parseCSVHeader(header)
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write(header)
+	for _, g := range games {
+		cw.Write(schema.record(len(header), g))
+	}
+	cw.Flush()
+	return cw.Error()
+}
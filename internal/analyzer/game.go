@@ -0,0 +1,252 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Game is a recorded game with players in finishing order (winner first) and
+// optional metadata about how it was played.
+type Game struct {
+	ID        string    `json:"id"`
+	Date      string    `json:"date"`
+	Timestamp time.Time `json:"timestamp"`
+	Rankings  []string  `json:"rankings"`
+	TableZap  string    `json:"table_zap"`
+	DrawGame  string    `json:"draw_game"`
+	Notes     string    `json:"notes,omitempty"`
+	// Line is the game's position in its source (CSV line or sheet row), 0 when unknown.
+	Line int `json:"line,omitempty"`
+	// LineID is set when the source has no ID for the game and ID is its
+	// line number, which moves when rows are inserted or deleted above it.
+	LineID bool `json:"-"`
+	// TrailingNotes is set when Notes is the free text after the players of
+	// a headerless CSV row rather than a notes column.
+	TrailingNotes bool `json:"-"`
+
+	// Turns is the number of turns the game lasted, 0 when unknown.
+	Turns int `json:"turns,omitempty"`
+	// TurnOrder lists the players by seat; TurnOrder[0] went first.
+	TurnOrder []string `json:"turn_order,omitempty"`
+	// Eliminations records which player knocked out which.
+	Eliminations []Elimination `json:"eliminations,omitempty"`
+	// Decks maps players to the deck they played, when recorded.
+	Decks map[string]string `json:"decks,omitempty"`
+
+	// Status is whether the game is scored. Games from the game log have
+	// none and are scored; games recorded through the server wait for their
+	// players to confirm them.
+	Status GameStatus `json:"status,omitempty"`
+	// ConfirmedBy lists the players who confirmed the game, in order.
+	ConfirmedBy []string `json:"confirmed_by,omitempty"`
+	// ApprovedBy names the scorekeeper who confirmed the game for its players.
+	ApprovedBy string `json:"approved_by,omitempty"`
+	// RejectedBy names who disputed the game.
+	RejectedBy string `json:"rejected_by,omitempty"`
+}
+
+// GameStatus is where a game is in the confirmation workflow.
+type GameStatus string
+
+const (
+	// Pending games wait for their players to confirm them and aren't scored yet.
+	Pending GameStatus = "pending"
+	// Confirmed games are scored.
+	Confirmed GameStatus = "confirmed"
+	// Rejected games were disputed and aren't scored.
+	Rejected GameStatus = "rejected"
+)
+
+// Scored reports whether g counts towards the ratings: it was confirmed or
+// never needed confirming.
+func (g Game) Scored() bool {
+	return g.Status == "" || g.Status == Confirmed
+}
+
+// Players returns everyone who played in g, with teams split into their members.
+func (g Game) Players() []string {
+	var players []string
+	for _, name := range g.Rankings {
+		players = append(players, strings.Split(name, TeamSeparator)...)
+	}
+	return players
+}
+
+// GameSource loads the game log from wherever it is kept.
+type GameSource interface {
+	Games(ctx context.Context) ([]Game, error)
+}
+
+// GameWriter is a game source that new games can be recorded to.
+type GameWriter interface {
+	AppendGame(ctx context.Context, g Game) error
+}
+
+// Warning reports a problem with one game that did not stop the log from loading.
+type Warning struct {
+	Line    int    `json:"line,omitempty"`
+	GameID  string `json:"game_id"`
+	Message string `json:"message"`
+}
+
+func (w Warning) String() string {
+	if w.Line > 0 {
+		return fmt.Sprintf("line %d (game %s): %s", w.Line, w.GameID, w.Message)
+	}
+	if w.GameID == "" {
+		return w.Message
+	}
+	return fmt.Sprintf("game %s: %s", w.GameID, w.Message)
+}
+
+// WarningSource is a GameSource that can also report per-game warnings.
+type WarningSource interface {
+	GameSource
+	GamesWithWarnings(ctx context.Context) ([]Game, []Warning, error)
+}
+
+// LoadGames fetches the games from src along with any warnings it reports.
+func LoadGames(ctx context.Context, src GameSource) ([]Game, []Warning, error) {
+	if ws, ok := src.(WarningSource); ok {
+		return ws.GamesWithWarnings(ctx)
+	}
+	games, err := src.Games(ctx)
+	return games, nil, err
+}
+
+// DateLayouts are the default date formats accepted by DateParser, tried in order.
+var DateLayouts = []string{
+	"1/2/2006",
+	"1/2/2006 15:04:05",
+	"2006-01-02",
+	"2006-01-02 15:04:05",
+	time.RFC3339,
+	time.RFC1123,
+}
+
+// DateParser parses game dates written in any of several layouts.
+type DateParser struct {
+	// Layouts are tried in order; DateLayouts is used when empty.
+	Layouts []string
+	// Location is the time zone of dates that don't name one; UTC when nil.
+	Location *time.Location
+}
+
+// Parse returns the time of the first layout that matches s.
+func (p DateParser) Parse(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, fmt.Errorf("missing date")
+	}
+	layouts := p.Layouts
+	if len(layouts) == 0 {
+		layouts = DateLayouts
+	}
+	loc := p.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date %q", s)
+}
+
+// ParseDate parses a game date in any of DateLayouts, interpreting dates
+// without a zone as UTC.
+func ParseDate(s string) (time.Time, error) {
+	return DateParser{}.Parse(s)
+}
+
+// SortChronologically orders games oldest first. The sort is stable so games
+// sharing a timestamp keep their source order.
+func SortChronologically(games []Game) {
+	sort.SliceStable(games, func(i, j int) bool { return games[i].Timestamp.Before(games[j].Timestamp) })
+}
+
+// Elimination records that Killer knocked Victim out of a game.
+type Elimination struct {
+	Killer string `json:"killer"`
+	Victim string `json:"victim"`
+}
+
+// ParseTurns parses a turn count cell; an empty cell means unknown (0).
+func ParseTurns(cell string) (int, error) {
+	cell = strings.TrimSpace(cell)
+	if cell == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(cell)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid turn count %q", cell)
+	}
+	return n, nil
+}
+
+// ParseTurnOrder parses a comma separated list of players in seat order,
+// e.g. "Dylan, Jacob, Marshall" where Dylan went first.
+func ParseTurnOrder(cell string) []string {
+	var order []string
+	for _, name := range strings.Split(cell, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			order = append(order, name)
+		}
+	}
+	return order
+}
+
+// CheckTurnOrder reports a turn order naming someone who didn't play in g,
+// or a player twice. Seat statistics skip such games.
+func CheckTurnOrder(g Game) error {
+	seen := make(map[string]bool, len(g.TurnOrder))
+	for _, name := range g.TurnOrder {
+		switch {
+		case seen[name]:
+			return fmt.Errorf("invalid turn order: %s is listed twice", name)
+		case !slices.Contains(g.Players(), name) && !slices.Contains(g.Rankings, name):
+			return fmt.Errorf("invalid turn order: %s didn't play", name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+// ParseEliminations parses a comma separated list of "Killer>Victim" pairs,
+// e.g. "Jacob>Dylan, Jacob>Marshall".
+func ParseEliminations(cell string) ([]Elimination, error) {
+	var elims []Elimination
+	for _, entry := range strings.Split(cell, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		killer, victim, ok := strings.Cut(entry, ">")
+		killer, victim = strings.TrimSpace(killer), strings.TrimSpace(victim)
+		if !ok || killer == "" || victim == "" {
+			return nil, fmt.Errorf("invalid elimination %q: want Killer>Victim", entry)
+		}
+		elims = append(elims, Elimination{Killer: killer, Victim: victim})
+	}
+	return elims, nil
+}
+
+// FormatTurnOrder is the inverse of ParseTurnOrder.
+func FormatTurnOrder(order []string) string {
+	return strings.Join(order, ", ")
+}
+
+// FormatEliminations is the inverse of ParseEliminations.
+func FormatEliminations(elims []Elimination) string {
+	parts := make([]string, len(elims))
+	for i, e := range elims {
+		parts[i] = e.Killer + ">" + e.Victim
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,236 @@
+package analyzer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JSONLSource reads and writes a game log in JSON Lines format: one JSON
+// object per line, oldest game first. Blank lines and lines starting with #
+// are ignored. A game looks like:
+//
+//	{"id":"12","timestamp":"2024-03-04T20:00:00Z","date":"3/4/2024",
+//	 "placements":[{"players":["Marshall"],"deck":"Atraxa"},{"players":["Dylan","Sara"]}],
+//	 "table_zap":true,"draw":false,"turns":9,"turn_order":["Dylan","Sara","Marshall"],
+//	 "eliminations":[{"killer":"Marshall","victim":"Dylan"}],"notes":"..."}
+//
+// Placements are in finishing order, winner first. A placement with several
+// players is a team and is rated as a single player named by joining them
+// with "/". timestamp is required unless date is given; date is the original
+// date text, parsed with Dates when there is no timestamp.
+type JSONLSource struct {
+	Path string
+	// Dates parses date when a game has no timestamp.
+	Dates DateParser
+	// Strict drops games whose date can't be parsed instead of keeping them.
+	Strict bool
+}
+
+// jsonlGame is one line of a JSON Lines game log.
+type jsonlGame struct {
+	ID           string           `json:"id,omitempty"`
+	Timestamp    *time.Time       `json:"timestamp,omitempty"`
+	Date         string           `json:"date,omitempty"`
+	Placements   []jsonlPlacement `json:"placements"`
+	TableZap     bool             `json:"table_zap,omitempty"`
+	Draw         bool             `json:"draw,omitempty"`
+	Turns        int              `json:"turns,omitempty"`
+	TurnOrder    []string         `json:"turn_order,omitempty"`
+	Eliminations []Elimination    `json:"eliminations,omitempty"`
+	Notes        string           `json:"notes,omitempty"`
+}
+
+// jsonlPlacement is one finishing position: a player or a team.
+type jsonlPlacement struct {
+	Players []string `json:"players"`
+	Deck    string   `json:"deck,omitempty"`
+}
+
+// TeamSeparator joins the members of a team into the name it is rated under.
+const TeamSeparator = "/"
+
+// Games implements GameSource, dropping any warnings.
+func (s JSONLSource) Games(ctx context.Context) ([]Game, error) {
+	games, _, err := s.GamesWithWarnings(ctx)
+	return games, err
+}
+
+// GamesWithWarnings implements WarningSource. Games are returned in
+// chronological order with file order breaking ties; a game without an id is
+// identified by its line number. Malformed JSON is an error.
+func (s JSONLSource) GamesWithWarnings(ctx context.Context) ([]Game, []Warning, error) {
+	file, err := os.Open(s.Path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open game log: %w", err)
+	}
+	defer file.Close()
+
+	var games []Game
+	var warnings []Warning
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 || text[0] == '#' {
+			continue
+		}
+		var entry jsonlGame
+		dec := json.NewDecoder(bytes.NewReader(text))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&entry); err != nil {
+			return nil, nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		g := entry.game()
+		if len(g.Rankings) == 0 {
+			continue
+		}
+		g.Line = line
+		if g.ID == "" {
+			g.ID, g.LineID = strconv.Itoa(line), true
+		}
+		if err := CheckTurnOrder(g); err != nil {
+			warnings = append(warnings, Warning{Line: line, GameID: g.ID, Message: err.Error()})
+		}
+
+		if entry.Timestamp != nil {
+			g.Timestamp = *entry.Timestamp
+		} else {
+			ts, err := s.Dates.Parse(g.Date)
+			if err != nil {
+				if s.Strict {
+					warnings = append(warnings, Warning{Line: line, GameID: g.ID, Message: err.Error() + "; game skipped"})
+					continue
+				}
+				warnings = append(warnings, Warning{Line: line, GameID: g.ID, Message: err.Error()})
+				if len(games) > 0 {
+					ts = games[len(games)-1].Timestamp
+				}
+			}
+			g.Timestamp = ts
+		}
+		games = append(games, g)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error reading game log: %w", err)
+	}
+	SortChronologically(games)
+	return games, warnings, nil
+}
+
+// game converts a log entry to the shared game model.
+func (e jsonlGame) game() Game {
+	g := Game{
+		ID:           e.ID,
+		Date:         e.Date,
+		Turns:        e.Turns,
+		TurnOrder:    e.TurnOrder,
+		Eliminations: e.Eliminations,
+		Notes:        e.Notes,
+	}
+	if e.TableZap {
+		g.TableZap = "TRUE"
+	}
+	if e.Draw {
+		g.DrawGame = "TRUE"
+	}
+	for _, p := range e.Placements {
+		name := strings.Join(p.Players, TeamSeparator)
+		if name == "" {
+			continue
+		}
+		g.Rankings = append(g.Rankings, name)
+		if p.Deck != "" {
+			if g.Decks == nil {
+				g.Decks = make(map[string]string)
+			}
+			g.Decks[name] = p.Deck
+		}
+	}
+	if g.Date == "" && e.Timestamp != nil {
+		g.Date = e.Timestamp.Format(time.DateOnly)
+	}
+	return g
+}
+
+// newJSONLGame converts a game to a log entry.
+func newJSONLGame(g Game) jsonlGame {
+	e := jsonlGame{
+		ID:           g.ID,
+		Date:         g.Date,
+		TableZap:     isTrue(g.TableZap),
+		Draw:         isTrue(g.DrawGame),
+		Turns:        g.Turns,
+		TurnOrder:    g.TurnOrder,
+		Eliminations: g.Eliminations,
+		Notes:        g.Notes,
+	}
+	if !g.Timestamp.IsZero() {
+		ts := g.Timestamp
+		e.Timestamp = &ts
+	}
+	for _, name := range g.Rankings {
+		e.Placements = append(e.Placements, jsonlPlacement{Players: strings.Split(name, TeamSeparator), Deck: g.Decks[name]})
+	}
+	return e
+}
+
+// isTrue reports whether a flag cell such as "TRUE" or "yes" is set.
+func isTrue(cell string) bool {
+	switch strings.ToLower(strings.TrimSpace(cell)) {
+	case "true", "yes", "y", "1", "x":
+		return true
+	}
+	return false
+}
+
+// WriteJSONL writes games to w in the JSONLSource format, one per line.
+func WriteJSONL(w io.Writer, games []Game) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for _, g := range games {
+		if err := enc.Encode(newJSONLGame(g)); err != nil {
+			return fmt.Errorf("failed to write game %s: %w", g.ID, err)
+		}
+	}
+	return nil
+}
+
+// AppendGame implements GameWriter by adding g as a new line at the end of the file.
+func (s JSONLSource) AppendGame(ctx context.Context, g Game) error {
+	if len(g.Rankings) < 2 {
+		return fmt.Errorf("invalid game: need at least 2 players, got %d", len(g.Rankings))
+	}
+	if g.Timestamp.IsZero() {
+		ts, err := s.Dates.Parse(g.Date)
+		if err != nil {
+			return err
+		}
+		g.Timestamp = ts
+	}
+	data, err := os.ReadFile(s.Path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read game log: %w", err)
+	}
+	file, err := os.OpenFile(s.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open game log: %w", err)
+	}
+	defer file.Close()
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		if _, err := file.WriteString("\n"); err != nil {
+			return fmt.Errorf("failed to write game log: %w", err)
+		}
+	}
+	if err := WriteJSONL(file, []Game{g}); err != nil {
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,102 @@
+package analyzer
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONLRoundTrip(t *testing.T) {
+	ts := time.Date(2024, 3, 4, 20, 0, 0, 0, time.UTC)
+	want := []Game{
+		{
+			ID: "1", Date: "3/4/2024", Timestamp: ts,
+			Rankings:     []string{"Marshall", "Dylan/Sara", "Colton"},
+			DrawGame:     "TRUE",
+			Turns:        9,
+			TurnOrder:    []string{"Dylan", "Sara", "Marshall", "Colton"},
+			Eliminations: []Elimination{{Killer: "Marshall", Victim: "Colton"}},
+			Decks:        map[string]string{"Marshall": "Atraxa"},
+			Notes:        "two-headed giant, \"quoted\"",
+		},
+		{ID: "2", Date: "3/5/2024", Timestamp: ts.AddDate(0, 0, 1), Rankings: []string{"Colton", "Marshall"}},
+	}
+	var buf bytes.Buffer
+	if err := WriteJSONL(&buf, want); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "games.jsonl")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, warnings, err := JSONLSource{Path: path}.GamesWithWarnings(context.Background())
+	if err != nil || len(warnings) != 0 {
+		t.Fatalf("unexpected error %v or warnings %v", err, warnings)
+	}
+	for i := range got {
+		got[i].Line = 0
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("round trip mismatch:\n got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestJSONLSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "games.jsonl")
+	data := strings.Join([]string{
+		`# history moved from the sheet`,
+		`{"date":"1/3/2024","placements":[{"players":["B"]},{"players":["A"]}]}`,
+		``,
+		`{"id":"x","timestamp":"2024-01-02T00:00:00Z","placements":[{"players":["A"]},{"players":["B"]}]}`,
+		`{"date":"someday","placements":[{"players":["A"]},{"players":["C"]}]}`,
+	}, "\n")
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	src := JSONLSource{Path: path}
+	games, warnings, err := src.GamesWithWarnings(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(games) != 3 || games[0].ID != "x" || games[1].ID != "5" || games[2].ID != "2" {
+		t.Fatalf("unexpected games %+v", games)
+	}
+	if len(warnings) != 1 || warnings[0].Line != 5 {
+		t.Fatalf("expected a warning for line 5, got %v", warnings)
+	}
+
+	if err := src.AppendGame(context.Background(), Game{Date: "1/4/2024", Rankings: []string{"C", "A"}}); err != nil {
+		t.Fatal(err)
+	}
+	games, err = src.Games(context.Background())
+	if err != nil || len(games) != 4 || games[3].Line != 6 {
+		t.Fatalf("expected the appended game on line 6, got %+v, %v", games, err)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"placements":[],"winner":"A"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := src.Games(context.Background()); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Fatalf("expected an error for the unknown field on line 1, got %v", err)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	games := []Game{
+		{ID: "1", Date: "1/2/2024", Rankings: []string{"A", "B", "C"}, Turns: 7},
+		{ID: "2", Date: "1/3/2024", Rankings: []string{"B", "A"}, Notes: "rematch"},
+	}
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, games); err != nil {
+		t.Fatal(err)
+	}
+	want := "id,date,player1,player2,player3,notes,turns\n1,1/2/2024,A,B,C,,7\n2,1/3/2024,B,A,,rematch,\n"
+	if buf.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
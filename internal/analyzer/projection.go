@@ -0,0 +1,259 @@
+package analyzer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"os"
+	"sort"
+	"strings"
+
+	elogo "github.com/kortemy/elo-go"
+)
+
+// ProjectionConfig controls a Monte Carlo projection of the rest of a season.
+type ProjectionConfig struct {
+	// Schedule lists the remaining games; each entry holds the players of one pod
+	// in any order. When empty, pods are drawn at random using GamesPerPlayer and PodSize.
+	Schedule [][]string
+	// GamesPerPlayer is the expected number of remaining games for every rated player.
+	GamesPerPlayer int
+	// PodSize is the number of players per randomly drawn game.
+	PodSize int
+	// Simulations is the number of seasons to simulate.
+	Simulations int
+	// Seed makes a projection reproducible; the same seed yields the same result.
+	Seed uint64
+}
+
+// Projection summarizes one player's simulated finishes.
+type Projection struct {
+	Player      string
+	Rating      int
+	MeanRating  float64
+	MeanRank    float64
+	RankCounts  []int // RankCounts[r] is the number of seasons finished at rank r+1
+	Simulations int
+}
+
+// Chance returns the probability that the player finishes at exactly rank (1-based).
+func (p Projection) Chance(rank int) float64 {
+	if rank < 1 || rank > len(p.RankCounts) || p.Simulations == 0 {
+		return 0
+	}
+	return float64(p.RankCounts[rank-1]) / float64(p.Simulations)
+}
+
+// TopChance returns the probability that the player finishes within the top n.
+func (p Projection) TopChance(n int) float64 {
+	var total float64
+	for r := 1; r <= n; r++ {
+		total += p.Chance(r)
+	}
+	return total
+}
+
+// Project simulates the remainder of a season starting from ratings. Every
+// simulated game draws a finishing order from the same pairwise Elo expected
+// scores used by ScoreGame and is then scored with ScoreGame, so ratings move
+// within a simulated season exactly as they would for real games.
+// Results are ordered by chance to finish first, then mean rank, then name.
+func Project(elo *elogo.Elo, ratings map[string]int, cfg ProjectionConfig) ([]Projection, error) {
+	if cfg.Simulations <= 0 {
+		return nil, errors.New("simulations must be positive")
+	}
+	if len(cfg.Schedule) == 0 {
+		if cfg.GamesPerPlayer <= 0 {
+			return nil, errors.New("need a schedule or a positive number of games per player")
+		}
+		if cfg.PodSize < 2 {
+			return nil, fmt.Errorf("invalid pod size %d: need at least 2 players", cfg.PodSize)
+		}
+	}
+	for i, pod := range cfg.Schedule {
+		if len(pod) < 2 {
+			return nil, fmt.Errorf("scheduled game %d: need at least 2 players, got %d", i+1, len(pod))
+		}
+	}
+
+	// Collect every player that can appear in the standings, in a stable order.
+	start := make(map[string]int, len(ratings))
+	for name, r := range ratings {
+		start[name] = r
+	}
+	for _, pod := range cfg.Schedule {
+		for _, name := range pod {
+			if _, ok := start[name]; !ok {
+				start[name] = DefaultStartingScore
+			}
+		}
+	}
+	players := make([]string, 0, len(start))
+	for name := range start {
+		players = append(players, name)
+	}
+	sort.Strings(players)
+	if len(players) == 0 {
+		return nil, errors.New("no players to project")
+	}
+
+	index := make(map[string]int, len(players))
+	results := make([]Projection, len(players))
+	for i, name := range players {
+		index[name] = i
+		results[i] = Projection{
+			Player:      name,
+			Rating:      start[name],
+			RankCounts:  make([]int, len(players)),
+			Simulations: cfg.Simulations,
+		}
+	}
+
+	rng := rand.New(rand.NewPCG(cfg.Seed, cfg.Seed))
+	D := float64(elo.D)
+	for sim := 0; sim < cfg.Simulations; sim++ {
+		scores := make(map[string]int, len(start))
+		for name, r := range start {
+			scores[name] = r
+		}
+		pods := cfg.Schedule
+		if len(pods) == 0 {
+			pods = drawPods(rng, players, cfg.GamesPerPlayer, cfg.PodSize)
+		}
+		for _, pod := range pods {
+			order := simulateFinish(rng, pod, scores, D)
+			if err := ScoreGame(elo, scores, order); err != nil {
+				return nil, err
+			}
+		}
+		for rank, fs := range CalculateFinalScores(scores) {
+			p := &results[index[fs.Player]]
+			p.RankCounts[rank]++
+			p.MeanRank += float64(rank + 1)
+			p.MeanRating += float64(fs.EloScore)
+		}
+	}
+
+	for i := range results {
+		results[i].MeanRank /= float64(cfg.Simulations)
+		results[i].MeanRating /= float64(cfg.Simulations)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].RankCounts[0] != results[j].RankCounts[0] {
+			return results[i].RankCounts[0] > results[j].RankCounts[0]
+		}
+		if results[i].MeanRank != results[j].MeanRank {
+			return results[i].MeanRank < results[j].MeanRank
+		}
+		return results[i].Player < results[j].Player
+	})
+	return results, nil
+}
+
+// simulateFinish draws a finishing order (winner first) for the players in pod.
+// Places are filled one at a time with each remaining player chosen with weight
+// 10^(R/D), which reproduces ExpectedScore for every pair of players.
+func simulateFinish(rng *rand.Rand, pod []string, scores map[string]int, d float64) []string {
+	remaining := append([]string(nil), pod...)
+	order := make([]string, 0, len(pod))
+	weights := make([]float64, len(pod))
+	for len(remaining) > 0 {
+		maxRating := math.Inf(-1)
+		for _, name := range remaining {
+			maxRating = math.Max(maxRating, float64(rating(scores, name)))
+		}
+		var total float64
+		for i, name := range remaining {
+			weights[i] = math.Pow(10, (float64(rating(scores, name))-maxRating)/d)
+			total += weights[i]
+		}
+		pick := len(remaining) - 1
+		x := rng.Float64() * total
+		for i := range remaining {
+			if x < weights[i] {
+				pick = i
+				break
+			}
+			x -= weights[i]
+		}
+		order = append(order, remaining[pick])
+		remaining = append(remaining[:pick], remaining[pick+1:]...)
+	}
+	return order
+}
+
+// drawPods builds a random schedule in which every player plays games times.
+// Players with the most games left are seated first so pods stay full; the last
+// pods may be smaller when the counts don't divide evenly.
+func drawPods(rng *rand.Rand, players []string, games, podSize int) [][]string {
+	left := make(map[string]int, len(players))
+	for _, name := range players {
+		left[name] = games
+	}
+	var pods [][]string
+	for {
+		candidates := make([]string, 0, len(players))
+		for _, name := range players {
+			if left[name] > 0 {
+				candidates = append(candidates, name)
+			}
+		}
+		if len(candidates) < 2 {
+			return pods
+		}
+		rng.Shuffle(len(candidates), func(i, j int) {
+			candidates[i], candidates[j] = candidates[j], candidates[i]
+		})
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return left[candidates[i]] > left[candidates[j]]
+		})
+		pod := candidates[:min(podSize, len(candidates))]
+		for _, name := range pod {
+			left[name]--
+		}
+		pods = append(pods, append([]string(nil), pod...))
+	}
+}
+
+func rating(scores map[string]int, name string) int {
+	if r, ok := scores[name]; ok {
+		return r
+	}
+	return DefaultStartingScore
+}
+
+// ReadSchedule reads a CSV of remaining games, one pod per row with the
+// players listed in any order. Empty cells are ignored.
+func ReadSchedule(path string) ([][]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open schedule file: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	var schedule [][]string
+	for {
+		record, err := reader.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("error reading schedule: %w", err)
+		}
+		pod := make([]string, 0, len(record))
+		for _, cell := range record {
+			if name := strings.TrimSpace(cell); name != "" {
+				pod = append(pod, name)
+			}
+		}
+		if len(pod) > 0 {
+			schedule = append(schedule, pod)
+		}
+	}
+	return schedule, nil
+}
@@ -0,0 +1,58 @@
+package analyzer
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestProjectDeterministicWithSeed(t *testing.T) {
+	ratings := map[string]int{"A": 1700, "B": 1500, "C": 1500, "D": 1300}
+	cfg := ProjectionConfig{GamesPerPlayer: 5, PodSize: 4, Simulations: 500, Seed: 42}
+
+	first, err := Project(InitializeElo(), ratings, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := Project(InitializeElo(), ratings, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Fatalf("same seed produced different projections:\n%v\n%v", first, second)
+	}
+
+	if first[0].Player != "A" {
+		t.Fatalf("expected highest rated player to be most likely champion, got %v", first[0].Player)
+	}
+	var total float64
+	for _, p := range first {
+		total += p.Chance(1)
+		if top := p.TopChance(len(ratings)); math.Abs(top-1) > 1e-9 {
+			t.Fatalf("%s: chances over all ranks should sum to 1, got %v", p.Player, top)
+		}
+	}
+	if math.Abs(total-1) > 1e-9 {
+		t.Fatalf("chances to finish first should sum to 1, got %v", total)
+	}
+}
+
+func TestProjectSchedule(t *testing.T) {
+	ratings := map[string]int{"A": 1500, "B": 1500}
+	cfg := ProjectionConfig{
+		Schedule:    [][]string{{"A", "B", "New"}},
+		Simulations: 100,
+		Seed:        7,
+	}
+	results, err := Project(InitializeElo(), ratings, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected scheduled newcomer to be projected, got %d players", len(results))
+	}
+
+	if _, err := Project(InitializeElo(), ratings, ProjectionConfig{Simulations: 10}); err == nil {
+		t.Fatalf("expected error without schedule or games per player")
+	}
+}
@@ -0,0 +1,203 @@
+package analyzer
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	elogo "github.com/kortemy/elo-go"
+)
+
+// PodSeatStats holds the per-seat win records of games with the same number of players.
+type PodSeatStats struct {
+	PodSize int        `json:"pod_size"`
+	Seats   []SeatStat `json:"seats"`
+}
+
+// SeatAdvantage maps a pod size to the rating bonus of each seat; index 0 is
+// the player who went first. Offsets for a pod size average to zero.
+type SeatAdvantage map[int][]float64
+
+// SeatAdjustment compares a player's rating with and without seat adjustment.
+type SeatAdjustment struct {
+	Player   string `json:"player"`
+	Rating   int    `json:"rating"`
+	Adjusted int    `json:"adjusted_rating"`
+	Delta    int    `json:"delta"`
+}
+
+// seats returns the seat of every ranked player (same order as Rankings), or
+// nil when the game has no turn order covering exactly its players.
+func seats(g Game) []int {
+	if len(g.TurnOrder) != len(g.Rankings) || len(g.Rankings) < 2 {
+		return nil
+	}
+	seatOf := make(map[string]int, len(g.TurnOrder))
+	for i, name := range g.TurnOrder {
+		seatOf[name] = i
+	}
+	out := make([]int, len(g.Rankings))
+	for i, name := range g.Rankings {
+		seat, ok := seatOf[name]
+		if !ok {
+			return nil
+		}
+		out[i] = seat
+	}
+	return out
+}
+
+// SeatWinRatesByPodSize computes win rates by seat separately for each pod
+// size, ordered by pod size. Only games whose turn order lists exactly the
+// ranked players are counted.
+func SeatWinRatesByPodSize(games []Game) []PodSeatStats {
+	byPod := make(map[int][]SeatStat)
+	for _, g := range games {
+		st := seats(g)
+		if st == nil {
+			continue
+		}
+		n := len(st)
+		if byPod[n] == nil {
+			byPod[n] = make([]SeatStat, n)
+			for i := range byPod[n] {
+				byPod[n][i].Seat = i + 1
+			}
+		}
+		for i := range byPod[n] {
+			byPod[n][i].Games++
+		}
+		byPod[n][st[0]].Wins++
+	}
+
+	out := make([]PodSeatStats, 0, len(byPod))
+	for n, s := range byPod {
+		out = append(out, PodSeatStats{PodSize: n, Seats: s})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].PodSize < out[j].PodSize })
+	return out
+}
+
+// EstimateSeatAdvantage converts seat win rates into rating offsets, much like
+// home-field advantage in Elo. With equally rated players the chance to win
+// from seat s is 10^(o_s/D) / sum_k 10^(o_k/D), so o_s = D*log10(p_s) up to a
+// constant. Win rates are smoothed towards 1/n with one pseudo-win per seat so
+// small samples don't produce extreme offsets.
+func EstimateSeatAdvantage(games []Game, d float64) SeatAdvantage {
+	adv := make(SeatAdvantage)
+	for _, pod := range SeatWinRatesByPodSize(games) {
+		n := float64(pod.PodSize)
+		offsets := make([]float64, pod.PodSize)
+		var mean float64
+		for i, s := range pod.Seats {
+			p := (float64(s.Wins) + 1) / (float64(s.Games) + n)
+			offsets[i] = d * math.Log10(p)
+			mean += offsets[i] / n
+		}
+		for i := range offsets {
+			offsets[i] -= mean
+		}
+		adv[pod.PodSize] = offsets
+	}
+	return adv
+}
+
+// ScoreGameWithSeats scores a game like ScoreGame, adding each player's seat
+// offset to their rating when computing expected scores. Games without a
+// usable turn order, or pod sizes without an estimate, are scored normally.
+func ScoreGameWithSeats(elo *elogo.Elo, scores map[string]int, g Game, adv SeatAdvantage) error {
+	st := seats(g)
+	table, ok := adv[len(g.Rankings)]
+	if st == nil || !ok {
+		return ScoreGame(elo, scores, g.Rankings)
+	}
+	offsets := make([]float64, len(st))
+	for i, seat := range st {
+		offsets[i] = table[seat]
+	}
+	return scoreGame(elo, scores, g.Rankings, offsets)
+}
+
+// RateGames replays games in order and returns the resulting ratings. A nil
+// adv rates without seat adjustment. Games with fewer than two players are skipped.
+func RateGames(elo *elogo.Elo, games []Game, adv SeatAdvantage) (map[string]int, error) {
+	scores := make(map[string]int)
+	if err := ReplayGames(elo, scores, games, adv); err != nil {
+		return nil, err
+	}
+	return scores, nil
+}
+
+// ReplayGames rates games in order on top of the existing ratings in scores,
+// updating them in place.
+func ReplayGames(elo *elogo.Elo, scores map[string]int, games []Game, adv SeatAdvantage) error {
+	for _, g := range games {
+		if len(g.Rankings) < 2 {
+			continue
+		}
+		if err := ScoreGameWithSeats(elo, scores, g, adv); err != nil {
+			return fmt.Errorf("failed to score game %s: %w", g.ID, err)
+		}
+	}
+	return nil
+}
+
+// RatingPoint is a player's rating after a game.
+type RatingPoint struct {
+	GameID    string    `json:"game_id"`
+	Date      string    `json:"date"`
+	Timestamp time.Time `json:"timestamp"`
+	Rating    int       `json:"rating"`
+}
+
+// RatingHistory replays games like RateGames and returns each player's rating
+// after every game they played, oldest first.
+func RatingHistory(elo *elogo.Elo, games []Game, adv SeatAdvantage) (map[string][]RatingPoint, error) {
+	scores := make(map[string]int)
+	history := make(map[string][]RatingPoint)
+	for _, g := range games {
+		if len(g.Rankings) < 2 {
+			continue
+		}
+		if err := ScoreGameWithSeats(elo, scores, g, adv); err != nil {
+			return nil, fmt.Errorf("failed to score game %s: %w", g.ID, err)
+		}
+		for _, name := range g.Rankings {
+			history[name] = append(history[name], RatingPoint{GameID: g.ID, Date: g.Date, Timestamp: g.Timestamp, Rating: scores[name]})
+		}
+	}
+	return history, nil
+}
+
+// CompareSeatAdjustment rates games with and without adv and reports the
+// difference per player, largest change first.
+func CompareSeatAdjustment(elo *elogo.Elo, games []Game, adv SeatAdvantage) ([]SeatAdjustment, error) {
+	plain, err := RateGames(elo, games, nil)
+	if err != nil {
+		return nil, err
+	}
+	adjusted, err := RateGames(elo, games, adv)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]SeatAdjustment, 0, len(plain))
+	for name, r := range plain {
+		out = append(out, SeatAdjustment{Player: name, Rating: r, Adjusted: adjusted[name], Delta: adjusted[name] - r})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		di, dj := abs(out[i].Delta), abs(out[j].Delta)
+		if di != dj {
+			return di > dj
+		}
+		return out[i].Player < out[j].Player
+	})
+	return out, nil
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
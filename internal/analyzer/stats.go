@@ -0,0 +1,153 @@
+package analyzer
+
+import "sort"
+
+// SeatStat is the win record of one seat position (1 = went first).
+type SeatStat struct {
+	Seat  int `json:"seat"`
+	Games int `json:"games"`
+	Wins  int `json:"wins"`
+}
+
+// WinRate returns the share of games won from this seat.
+func (s SeatStat) WinRate() float64 {
+	if s.Games == 0 {
+		return 0
+	}
+	return float64(s.Wins) / float64(s.Games)
+}
+
+// EliminationStat counts how often a player knocked others out and was knocked out.
+type EliminationStat struct {
+	Player       string `json:"player"`
+	Eliminations int    `json:"eliminations"`
+	Eliminated   int    `json:"eliminated"`
+}
+
+// HeadToHeadStat is one player's record against one opponent: how often they
+// finished ahead of (Wins) or behind (Losses) them in games they both played.
+type HeadToHeadStat struct {
+	Player   string `json:"player"`
+	Opponent string `json:"opponent"`
+	Games    int    `json:"games"`
+	Wins     int    `json:"wins"`
+	Losses   int    `json:"losses"`
+}
+
+// SeatWinRates computes win rates by seat position over games with a recorded
+// turn order. Games whose winner is missing from the turn order are skipped.
+func SeatWinRates(games []Game) []SeatStat {
+	var seats []SeatStat
+	for _, g := range games {
+		if len(g.TurnOrder) == 0 || len(g.Rankings) == 0 {
+			continue
+		}
+		winner := -1
+		for i, name := range g.TurnOrder {
+			if name == g.Rankings[0] {
+				winner = i
+			}
+		}
+		if winner < 0 {
+			continue
+		}
+		for len(seats) < len(g.TurnOrder) {
+			seats = append(seats, SeatStat{Seat: len(seats) + 1})
+		}
+		for i := range g.TurnOrder {
+			seats[i].Games++
+		}
+		seats[winner].Wins++
+	}
+	return seats
+}
+
+// EliminationStats tallies eliminations per player, most eliminations first.
+func EliminationStats(games []Game) []EliminationStat {
+	byPlayer := make(map[string]*EliminationStat)
+	get := func(name string) *EliminationStat {
+		st, ok := byPlayer[name]
+		if !ok {
+			st = &EliminationStat{Player: name}
+			byPlayer[name] = st
+		}
+		return st
+	}
+	for _, g := range games {
+		for _, e := range g.Eliminations {
+			get(e.Killer).Eliminations++
+			get(e.Victim).Eliminated++
+		}
+	}
+
+	out := make([]EliminationStat, 0, len(byPlayer))
+	for _, st := range byPlayer {
+		out = append(out, *st)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Eliminations != out[j].Eliminations {
+			return out[i].Eliminations > out[j].Eliminations
+		}
+		if out[i].Eliminated != out[j].Eliminated {
+			return out[i].Eliminated < out[j].Eliminated
+		}
+		return out[i].Player < out[j].Player
+	})
+	return out
+}
+
+// HeadToHead tallies every player's record against every opponent they have
+// shared a game with, sorted by player then opponent.
+func HeadToHead(games []Game) []HeadToHeadStat {
+	type pair struct{ player, opponent string }
+	records := make(map[pair]*HeadToHeadStat)
+	record := func(player, opponent string) *HeadToHeadStat {
+		st, ok := records[pair{player, opponent}]
+		if !ok {
+			st = &HeadToHeadStat{Player: player, Opponent: opponent}
+			records[pair{player, opponent}] = st
+		}
+		return st
+	}
+	for _, g := range games {
+		for i, ahead := range g.Rankings {
+			for _, behind := range g.Rankings[i+1:] {
+				if ahead == behind {
+					continue
+				}
+				w, l := record(ahead, behind), record(behind, ahead)
+				w.Games++
+				w.Wins++
+				l.Games++
+				l.Losses++
+			}
+		}
+	}
+
+	out := make([]HeadToHeadStat, 0, len(records))
+	for _, st := range records {
+		out = append(out, *st)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Player != out[j].Player {
+			return out[i].Player < out[j].Player
+		}
+		return out[i].Opponent < out[j].Opponent
+	})
+	return out
+}
+
+// AverageTurns returns the mean turn count over games that recorded one.
+func AverageTurns(games []Game) float64 {
+	var total, n int
+	for _, g := range games {
+		if g.Turns > 0 {
+			total += g.Turns
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return float64(total) / float64(n)
+}
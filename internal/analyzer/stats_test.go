@@ -0,0 +1,81 @@
+package analyzer
+
+import "testing"
+
+func TestGameMetadataStats(t *testing.T) {
+	elims, err := ParseEliminations("Jacob>Dylan, Jacob > Marshall")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	games := []Game{
+		{Rankings: []string{"Jacob", "Dylan", "Marshall"}, TurnOrder: ParseTurnOrder("Jacob, Dylan, Marshall"), Eliminations: elims, Turns: 8},
+		{Rankings: []string{"Dylan", "Jacob"}, TurnOrder: ParseTurnOrder("Dylan,Jacob"), Turns: 6},
+		{Rankings: []string{"Marshall", "Dylan"}},
+	}
+
+	seats := SeatWinRates(games)
+	if len(seats) != 3 || seats[0].Games != 2 || seats[0].Wins != 2 || seats[2].Games != 1 {
+		t.Fatalf("unexpected seat stats: %+v", seats)
+	}
+	top := EliminationStats(games)[0]
+	if top.Player != "Jacob" || top.Eliminations != 2 {
+		t.Fatalf("unexpected elimination leader: %+v", top)
+	}
+	if avg := AverageTurns(games); avg != 7 {
+		t.Fatalf("expected average of 7 turns, got %v", avg)
+	}
+	h2h := HeadToHead(games)
+	if len(h2h) != 6 || h2h[0] != (HeadToHeadStat{Player: "Dylan", Opponent: "Jacob", Games: 2, Wins: 1, Losses: 1}) {
+		t.Fatalf("unexpected head-to-head records: %+v", h2h)
+	}
+	if _, err := ParseEliminations("Jacob"); err == nil {
+		t.Fatalf("expected error for malformed elimination")
+	}
+	for order, ok := range map[string]bool{
+		"":                       true,
+		"Jacob, Dylan, Marshall": true,
+		"Dylan":                  true,
+		"Jacob, Dylan, Sara":     false,
+		"Jacob, Dylan, Jacob":    false,
+	} {
+		g := Game{Rankings: games[0].Rankings, TurnOrder: ParseTurnOrder(order)}
+		if err := CheckTurnOrder(g); (err == nil) != ok {
+			t.Errorf("turn order %q: error %v", order, err)
+		}
+	}
+	team := Game{Rankings: []string{"Dylan/Sara", "Jacob"}, TurnOrder: ParseTurnOrder("Jacob, Dylan, Sara")}
+	if err := CheckTurnOrder(team); err != nil {
+		t.Errorf("team turn order: %v", err)
+	}
+}
+
+func TestSeatAdvantage(t *testing.T) {
+	var games []Game
+	for i := 0; i < 10; i++ {
+		// whoever goes first wins
+		games = append(games,
+			Game{Rankings: []string{"A", "B", "C"}, TurnOrder: []string{"A", "B", "C"}},
+			Game{Rankings: []string{"B", "C", "A"}, TurnOrder: []string{"B", "C", "A"}},
+		)
+	}
+	adv := EstimateSeatAdvantage(games, 800)
+	offsets := adv[3]
+	if len(offsets) != 3 || offsets[0] <= 0 || offsets[2] >= 0 {
+		t.Fatalf("expected first seat advantage, got %v", offsets)
+	}
+	if sum := offsets[0] + offsets[1] + offsets[2]; sum > 1e-9 || sum < -1e-9 {
+		t.Fatalf("offsets should average to zero, got sum %v", sum)
+	}
+
+	plain := map[string]int{}
+	adjusted := map[string]int{}
+	if err := ScoreGameWithSeats(InitializeElo(), plain, games[0], nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := ScoreGameWithSeats(InitializeElo(), adjusted, games[0], adv); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if adjusted["A"] >= plain["A"] {
+		t.Fatalf("winning from the first seat should earn less when adjusted: %d vs %d", adjusted["A"], plain["A"])
+	}
+}
@@ -0,0 +1,257 @@
+// Package auth issues API tokens with roles and checks them on requests.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Role is what a token may do. Each role can do everything the roles before
+// it can.
+type Role string
+
+const (
+	// Viewer may read scores and games when the server is private.
+	Viewer Role = "viewer"
+	// Scorekeeper may also record and correct games and refresh the scores.
+	Scorekeeper Role = "scorekeeper"
+	// Admin may also void games.
+	Admin Role = "admin"
+)
+
+// Roles lists the roles for flags and errors.
+const Roles = "viewer, scorekeeper or admin"
+
+var roleOrder = []Role{Viewer, Scorekeeper, Admin}
+
+// ParseRole parses a role name.
+func ParseRole(name string) (Role, error) {
+	r := Role(strings.ToLower(strings.TrimSpace(name)))
+	if !slices.Contains(roleOrder, r) {
+		return "", fmt.Errorf("unknown role %q: want %s", name, Roles)
+	}
+	return r, nil
+}
+
+// Allows reports whether r may do what role may.
+func (r Role) Allows(role Role) bool {
+	return slices.Index(roleOrder, r) >= slices.Index(roleOrder, role)
+}
+
+// Token is an issued API token. Only a hash of its secret is kept.
+type Token struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Role      Role      `json:"role"`
+	Hash      string    `json:"hash"` // hex SHA-256 of the secret
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Tokens is a set of tokens kept in a JSON file. The file is reread when it
+// changes, so tokens minted or revoked by another process take effect
+// without a restart.
+type Tokens struct {
+	path string
+
+	mu      sync.Mutex
+	tokens  []Token
+	modTime time.Time
+	size    int64
+}
+
+// tokenFile is the layout of the tokens file.
+type tokenFile struct {
+	Tokens []Token `json:"tokens"`
+}
+
+// Open loads the tokens at path. A missing file is an empty set, created by
+// the first Mint.
+func Open(path string) (*Tokens, error) {
+	t := &Tokens{path: path}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.reload(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// reload rereads the file if it changed since it was last read. The caller holds t.mu.
+func (t *Tokens) reload() error {
+	info, err := os.Stat(t.path)
+	if errors.Is(err, os.ErrNotExist) {
+		t.tokens, t.modTime, t.size = nil, time.Time{}, 0
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read tokens: %w", err)
+	}
+	if info.ModTime().Equal(t.modTime) && info.Size() == t.size {
+		return nil
+	}
+	data, err := os.ReadFile(t.path)
+	if err != nil {
+		return fmt.Errorf("failed to read tokens: %w", err)
+	}
+	var f tokenFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("invalid tokens file %s: %w", t.path, err)
+	}
+	t.tokens, t.modTime, t.size = f.Tokens, info.ModTime(), info.Size()
+	return nil
+}
+
+// save writes the tokens atomically, readable only by the owner. The caller holds t.mu.
+func (t *Tokens) save() error {
+	data, err := json.MarshalIndent(tokenFile{Tokens: t.tokens}, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(t.path), ".tokens-*")
+	if err != nil {
+		return fmt.Errorf("failed to save tokens: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save tokens: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save tokens: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), t.path); err != nil {
+		return fmt.Errorf("failed to save tokens: %w", err)
+	}
+	// pick up the new file's mtime so the next call doesn't reread it
+	t.modTime, t.size = time.Time{}, 0
+	return t.reload()
+}
+
+// Mint issues a token for name with the given role and returns its secret,
+// which is not stored and can't be shown again.
+func (t *Tokens) Mint(name string, role Role) (string, Token, error) {
+	if _, err := ParseRole(string(role)); err != nil {
+		return "", Token{}, err
+	}
+	id, err := randomString(4)
+	if err != nil {
+		return "", Token{}, err
+	}
+	secret, err := randomString(24)
+	if err != nil {
+		return "", Token{}, err
+	}
+	secret = "gm_" + secret
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.reload(); err != nil {
+		return "", Token{}, err
+	}
+	tok := Token{ID: id, Name: name, Role: role, Hash: hash(secret), CreatedAt: time.Now().UTC().Truncate(time.Second)}
+	t.tokens = append(t.tokens, tok)
+	if err := t.save(); err != nil {
+		return "", Token{}, err
+	}
+	return secret, tok, nil
+}
+
+// Revoke removes the token with the given ID.
+func (t *Tokens) Revoke(id string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.reload(); err != nil {
+		return err
+	}
+	i := slices.IndexFunc(t.tokens, func(tok Token) bool { return tok.ID == id })
+	if i < 0 {
+		return fmt.Errorf("no token %q", id)
+	}
+	t.tokens = slices.Delete(t.tokens, i, i+1)
+	return t.save()
+}
+
+// List returns the tokens, oldest first.
+func (t *Tokens) List() ([]Token, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.reload(); err != nil {
+		return nil, err
+	}
+	return slices.Clone(t.tokens), nil
+}
+
+// Authenticate returns the token whose secret is given.
+func (t *Tokens) Authenticate(secret string) (Token, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	// keep using the tokens already loaded if the file is mid-write
+	_ = t.reload()
+	h := []byte(hash(secret))
+	for _, tok := range t.tokens {
+		if subtle.ConstantTimeCompare([]byte(tok.Hash), h) == 1 {
+			return tok, true
+		}
+	}
+	return Token{}, false
+}
+
+func hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+type contextKey struct{}
+
+// FromContext returns the token a request was authenticated with.
+func FromContext(ctx context.Context) (Token, bool) {
+	tok, ok := ctx.Value(contextKey{}).(Token)
+	return tok, ok
+}
+
+// Require wraps next so it is only called for requests carrying a token,
+// as "Authorization: Bearer <token>", whose role allows role. Missing or
+// unknown tokens get 401 and tokens with too small a role 403. The token is
+// available to next through FromContext.
+func (t *Tokens) Require(role Role, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secret, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || secret == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="guildmaster"`)
+			http.Error(w, "missing API token", http.StatusUnauthorized)
+			return
+		}
+		tok, ok := t.Authenticate(strings.TrimSpace(secret))
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="guildmaster", error="invalid_token"`)
+			http.Error(w, "invalid API token", http.StatusUnauthorized)
+			return
+		}
+		if !tok.Role.Allows(role) {
+			http.Error(w, fmt.Sprintf("%s token can't do this: need %s", tok.Role, role), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, tok)))
+	})
+}
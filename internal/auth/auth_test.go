@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMintAuthenticateRevoke(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	tokens, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret, tok, err := tokens.Mint("Sara", Scorekeeper)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(secret, "gm_") {
+		t.Errorf("secret %q lacks the gm_ prefix", secret)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), secret) {
+		t.Error("tokens file contains the secret")
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("tokens file mode = %v, %v; want 0600", info.Mode().Perm(), err)
+	}
+
+	got, ok := tokens.Authenticate(secret)
+	if !ok || got.ID != tok.ID || got.Role != Scorekeeper || got.Name != "Sara" {
+		t.Fatalf("Authenticate = %+v, %v; want %+v", got, ok, tok)
+	}
+	if _, ok := tokens.Authenticate(secret + "x"); ok {
+		t.Error("wrong secret authenticated")
+	}
+
+	if err := tokens.Revoke(tok.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := tokens.Authenticate(secret); ok {
+		t.Error("revoked token authenticated")
+	}
+	if err := tokens.Revoke(tok.ID); err == nil {
+		t.Error("revoking an unknown token succeeded")
+	}
+	if _, _, err := tokens.Mint("x", Role("owner")); err == nil {
+		t.Error("minted a token with an unknown role")
+	}
+}
+
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	server, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret, tok, err := cli.Mint("Dylan", Admin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := server.Authenticate(secret); !ok {
+		t.Fatal("token minted by another process not picked up")
+	}
+	if err := cli.Revoke(tok.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := server.Authenticate(secret); ok {
+		t.Fatal("token revoked by another process still authenticates")
+	}
+}
+
+func TestRoles(t *testing.T) {
+	if r, err := ParseRole(" Admin "); err != nil || r != Admin {
+		t.Errorf("ParseRole = %q, %v", r, err)
+	}
+	if _, err := ParseRole("owner"); err == nil {
+		t.Error("parsed unknown role")
+	}
+	tests := []struct {
+		r, role Role
+		want    bool
+	}{
+		{Admin, Scorekeeper, true},
+		{Scorekeeper, Scorekeeper, true},
+		{Scorekeeper, Admin, false},
+		{Viewer, Scorekeeper, false},
+		{Viewer, Viewer, true},
+	}
+	for _, tt := range tests {
+		if got := tt.r.Allows(tt.role); got != tt.want {
+			t.Errorf("%s.Allows(%s) = %v, want %v", tt.r, tt.role, got, tt.want)
+		}
+	}
+}
+
+func TestRequire(t *testing.T) {
+	tokens, err := Open(filepath.Join(t.TempDir(), "tokens.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	viewer, _, err := tokens.Mint("viewer", Viewer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keeper, _, err := tokens.Mint("keeper", Scorekeeper)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := tokens.Require(Scorekeeper, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tok, _ := FromContext(r.Context())
+		w.Write([]byte(tok.Name))
+	}))
+
+	tests := []struct {
+		auth string
+		code int
+		body string
+	}{
+		{"", http.StatusUnauthorized, ""},
+		{"Bearer nope", http.StatusUnauthorized, ""},
+		{"Basic " + keeper, http.StatusUnauthorized, ""},
+		{"Bearer " + viewer, http.StatusForbidden, ""},
+		{"Bearer " + keeper, http.StatusOK, "keeper"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/api/game", nil)
+		if tt.auth != "" {
+			req.Header.Set("Authorization", tt.auth)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tt.code {
+			t.Errorf("%q: status %d, want %d", tt.auth, rec.Code, tt.code)
+		}
+		if tt.code == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("%q: no WWW-Authenticate header", tt.auth)
+		}
+		if tt.body != "" && rec.Body.String() != tt.body {
+			t.Errorf("%q: body %q, want %q", tt.auth, rec.Body.String(), tt.body)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+// Package export writes rankings and game history as CSV, JSON, Markdown or HTML tables.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"mime"
+	"strconv"
+	"strings"
+
+	"github.com/dylanlott/guildmaster/internal/analyzer"
+)
+
+// Format is an output format.
+type Format string
+
+const (
+	CSV      Format = "csv"
+	JSON     Format = "json"
+	Markdown Format = "markdown"
+	HTML     Format = "html"
+)
+
+// Formats lists the supported formats for flags and errors.
+const Formats = "csv, json, markdown or html"
+
+// contentTypes maps each format to its media type.
+var contentTypes = map[Format]string{
+	CSV:      "text/csv",
+	JSON:     "application/json",
+	Markdown: "text/markdown",
+	HTML:     "text/html",
+}
+
+// ParseFormat parses a format name; "md" is accepted for Markdown.
+func ParseFormat(name string) (Format, error) {
+	f := Format(strings.ToLower(strings.TrimSpace(name)))
+	if f == "md" {
+		f = Markdown
+	}
+	if _, ok := contentTypes[f]; !ok {
+		return "", fmt.Errorf("unknown format %q: want %s", name, Formats)
+	}
+	return f, nil
+}
+
+// ContentType returns the HTTP Content-Type for f.
+func (f Format) ContentType() string {
+	return contentTypes[f] + "; charset=utf-8"
+}
+
+// Negotiate picks the format preferred by an HTTP Accept header. It reports
+// false when the header asks for none of them or accepts anything, leaving the
+// caller to use its default.
+func Negotiate(accept string) (Format, bool) {
+	best, bestQ := Format(""), 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		for f, ct := range contentTypes {
+			if ct == mediaType && q > bestQ {
+				best, bestQ = f, q
+			}
+		}
+	}
+	return best, best != ""
+}
+
+// Table is a titled table of values. Cells keep their Go types so JSON output
+// has numbers where the data has numbers.
+type Table struct {
+	Title   string
+	Columns []string
+	Rows    [][]any
+}
+
+// Write writes t to w in format f.
+func Write(w io.Writer, t Table, f Format) error {
+	switch f {
+	case CSV:
+		return writeCSV(w, t)
+	case JSON:
+		return writeJSON(w, t)
+	case Markdown:
+		return writeMarkdown(w, t)
+	case HTML:
+		return writeHTML(w, t)
+	default:
+		return fmt.Errorf("unknown format %q: want %s", f, Formats)
+	}
+}
+
+func writeCSV(w io.Writer, t Table) error {
+	cw := csv.NewWriter(w)
+	cw.Write(t.Columns)
+	for _, row := range t.Rows {
+		cw.Write(cells(row))
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeJSON writes the rows as an array of objects keyed by column name in snake_case.
+func writeJSON(w io.Writer, t Table) error {
+	keys := make([]string, len(t.Columns))
+	for i, c := range t.Columns {
+		keys[i] = strings.ReplaceAll(strings.ToLower(c), " ", "_")
+	}
+	// build each object by hand so keys keep the column order
+	var b strings.Builder
+	b.WriteString("[")
+	for i, row := range t.Rows {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString("{")
+		for j, v := range row {
+			if j > 0 {
+				b.WriteString(",")
+			}
+			k, _ := json.Marshal(keys[j])
+			val, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			b.Write(k)
+			b.WriteString(":")
+			b.Write(val)
+		}
+		b.WriteString("}")
+	}
+	b.WriteString("]\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeMarkdown(w io.Writer, t Table) error {
+	var b strings.Builder
+	if t.Title != "" {
+		fmt.Fprintf(&b, "## %s\n\n", t.Title)
+	}
+	line := func(cells []string) {
+		for i, c := range cells {
+			cells[i] = strings.ReplaceAll(c, "|", `\|`)
+		}
+		fmt.Fprintf(&b, "| %s |\n", strings.Join(cells, " | "))
+	}
+	line(append([]string(nil), t.Columns...))
+	sep := make([]string, len(t.Columns))
+	for i := range sep {
+		sep[i] = "---"
+	}
+	fmt.Fprintf(&b, "|%s|\n", strings.Join(sep, "|"))
+	for _, row := range t.Rows {
+		line(cells(row))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var htmlTable = template.Must(template.New("table").Parse(`<table>
+{{- with .Title }}
+  <caption>{{ . }}</caption>
+{{- end }}
+  <thead><tr>{{ range .Columns }}<th>{{ . }}</th>{{ end }}</tr></thead>
+  <tbody>
+{{- range .Rows }}
+    <tr>{{ range . }}<td>{{ . }}</td>{{ end }}</tr>
+{{- end }}
+  </tbody>
+</table>
+`))
+
+func writeHTML(w io.Writer, t Table) error {
+	rows := make([][]string, len(t.Rows))
+	for i, row := range t.Rows {
+		rows[i] = cells(row)
+	}
+	return htmlTable.Execute(w, struct {
+		Title   string
+		Columns []string
+		Rows    [][]string
+	}{t.Title, t.Columns, rows})
+}
+
+// cells formats a row of values as text.
+func cells(row []any) []string {
+	out := make([]string, len(row))
+	for i, v := range row {
+		out[i] = fmt.Sprint(v)
+	}
+	return out
+}
+
+// Rankings tabulates ratings, highest first.
+func Rankings(scores map[string]int) Table {
+	t := Table{Title: "Rankings", Columns: []string{"Rank", "Player", "Elo"}}
+	for i, s := range analyzer.CalculateFinalScores(scores) {
+		t.Rows = append(t.Rows, []any{i + 1, s.Player, s.EloScore})
+	}
+	return t
+}
+
+// History tabulates games in the order given.
+func History(games []analyzer.Game) Table {
+	t := Table{Title: "Game history", Columns: []string{"Game", "Date", "Players", "Notes"}}
+	for _, g := range games {
+		t.Rows = append(t.Rows, []any{g.ID, g.Date, strings.Join(g.Rankings, ", "), g.Notes})
+	}
+	return t
+}
+
+// HeadToHead tabulates every player's record against each opponent.
+func HeadToHead(games []analyzer.Game) Table {
+	t := Table{Title: "Head to head", Columns: []string{"Player", "Opponent", "Games", "Wins", "Losses", "Win rate"}}
+	for _, s := range analyzer.HeadToHead(games) {
+		rate := math.Round(float64(s.Wins)/float64(s.Games)*1000) / 1000
+		t.Rows = append(t.Rows, []any{s.Player, s.Opponent, s.Games, s.Wins, s.Losses, rate})
+	}
+	return t
+}
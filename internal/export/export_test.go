@@ -0,0 +1,56 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/dylanlott/guildmaster/internal/analyzer"
+)
+
+func TestNegotiate(t *testing.T) {
+	for accept, want := range map[string]Format{
+		"text/csv": CSV,
+		"text/html,application/xhtml+xml,*/*;q=0.8":       HTML,
+		"application/json;q=0.5, text/markdown;q=0.9":     Markdown,
+		"text/csv; charset=utf-8;q=0.1, application/json": JSON,
+		"*/*":       "",
+		"":          "",
+		"image/png": "",
+	} {
+		got, ok := Negotiate(accept)
+		if got != want || ok != (want != "") {
+			t.Errorf("Negotiate(%q) = %q, %v; want %q", accept, got, ok, want)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	tbl := History([]analyzer.Game{{ID: "1", Date: "1/2/2024", Rankings: []string{"A", "B"}, Notes: "a|b <i>"}})
+	want := map[Format]string{
+		CSV:      "Game,Date,Players,Notes\n1,1/2/2024,\"A, B\",a|b <i>\n",
+		JSON:     `[{"game":"1","date":"1/2/2024","players":"A, B","notes":"a|b \u003ci\u003e"}]` + "\n",
+		Markdown: "## Game history\n\n| Game | Date | Players | Notes |\n|---|---|---|---|\n| 1 | 1/2/2024 | A, B | a\\|b <i> |\n",
+	}
+	for f, w := range want {
+		var buf bytes.Buffer
+		if err := Write(&buf, tbl, f); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != w {
+			t.Errorf("%s:\n got %q\nwant %q", f, buf.String(), w)
+		}
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, tbl, HTML); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "<td>a|b &lt;i&gt;</td>") {
+		t.Errorf("expected escaped HTML cell, got:\n%s", buf.String())
+	}
+
+	rankings := Rankings(map[string]int{"A": 1520, "B": 1480})
+	if rankings.Rows[0][1] != "A" || rankings.Rows[1][0] != 2 {
+		t.Errorf("unexpected rankings %v", rankings.Rows)
+	}
+}
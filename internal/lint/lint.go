@@ -0,0 +1,236 @@
+// Package lint checks a game log for data entry mistakes.
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dylanlott/guildmaster/internal/analyzer"
+)
+
+// Severity ranks how serious an issue is.
+type Severity string
+
+const (
+	Error   Severity = "error"
+	Warning Severity = "warning"
+	Info    Severity = "info"
+)
+
+// Issue is a single problem found in the game log.
+type Issue struct {
+	Severity Severity `json:"severity"`
+	Check    string   `json:"check"`
+	GameID   string   `json:"game_id,omitempty"`
+	Line     int      `json:"line,omitempty"`
+	Message  string   `json:"message"`
+}
+
+func (i Issue) String() string {
+	loc := ""
+	switch {
+	case i.Line > 0:
+		loc = fmt.Sprintf("line %d (game %s): ", i.Line, i.GameID)
+	case i.GameID != "":
+		loc = fmt.Sprintf("game %s: ", i.GameID)
+	}
+	return fmt.Sprintf("%s: %s%s [%s]", i.Severity, loc, i.Message, i.Check)
+}
+
+// Options tunes the checks.
+type Options struct {
+	// Players is the roster of known names. When non-empty, any other name is reported.
+	Players []string
+}
+
+// Check runs every check over games, which are examined in source order
+// (by Line) so date ordering problems match what's in the file or sheet.
+// warnings from loading the source are included as issues.
+func Check(games []analyzer.Game, warnings []analyzer.Warning, opts Options) []Issue {
+	games = append([]analyzer.Game(nil), games...)
+	sort.SliceStable(games, func(i, j int) bool { return games[i].Line < games[j].Line })
+
+	var issues []Issue
+	for _, w := range warnings {
+		issues = append(issues, Issue{Severity: Warning, Check: "source", GameID: w.GameID, Line: w.Line, Message: w.Message})
+	}
+	issues = append(issues, checkGames(games)...)
+	issues = append(issues, checkNotes(games)...)
+	issues = append(issues, checkDateOrder(games)...)
+	issues = append(issues, checkNames(games, opts.Players)...)
+	return issues
+}
+
+// checkGames looks at each game on its own.
+func checkGames(games []analyzer.Game) []Issue {
+	var issues []Issue
+	for _, g := range games {
+		issue := func(sev Severity, check, format string, args ...any) {
+			issues = append(issues, Issue{Severity: sev, Check: check, GameID: g.ID, Line: g.Line, Message: fmt.Sprintf(format, args...)})
+		}
+		if len(g.Rankings) < 2 {
+			issue(Error, "single-player", "game has %d player(s); need at least 2 to score", len(g.Rankings))
+		}
+		seen := make(map[string]bool, len(g.Rankings))
+		for _, name := range g.Rankings {
+			if seen[name] {
+				issue(Error, "duplicate-player", "%s appears more than once", name)
+			}
+			seen[name] = true
+			if strings.Contains(name, "/") {
+				issue(Warning, "team-marker", "%q looks like a team; it is rated as a single player", name)
+			}
+		}
+		if g.TrailingNotes {
+			issue(Info, "trailing-columns", "free text after the players: %q", g.Notes)
+		}
+	}
+	return issues
+}
+
+// checkNotes reports notes made up only of player names, in any layout,
+// which usually means a gap in the player columns pushed players into the
+// notes.
+func checkNotes(games []analyzer.Game) []Issue {
+	players := make(map[string]bool)
+	for _, g := range games {
+		for _, name := range g.Rankings {
+			players[strings.ToLower(name)] = true
+		}
+	}
+	var issues []Issue
+	for _, g := range games {
+		words := strings.FieldsFunc(g.Notes, func(r rune) bool { return r == ',' || r == ' ' })
+		if len(words) == 0 {
+			continue
+		}
+		allPlayers := true
+		for _, w := range words {
+			allPlayers = allPlayers && players[strings.ToLower(w)]
+		}
+		if allPlayers {
+			issues = append(issues, Issue{Severity: Warning, Check: "notes-players", GameID: g.ID, Line: g.Line,
+				Message: fmt.Sprintf("notes %q look like player names; is there a gap in the player columns?", g.Notes)})
+		}
+	}
+	return issues
+}
+
+// checkDateOrder reports games dated before the game preceding them.
+func checkDateOrder(games []analyzer.Game) []Issue {
+	var issues []Issue
+	for i := 1; i < len(games); i++ {
+		prev, g := games[i-1], games[i]
+		if g.Timestamp.Before(prev.Timestamp) {
+			issues = append(issues, Issue{
+				Severity: Warning,
+				Check:    "date-order",
+				GameID:   g.ID,
+				Line:     g.Line,
+				Message:  fmt.Sprintf("dated %s, before the previous game (%s)", g.Date, prev.Date),
+			})
+		}
+	}
+	return issues
+}
+
+// checkNames reports names missing from the roster and pairs of names that
+// are probably the same player spelled differently.
+func checkNames(games []analyzer.Game, roster []string) []Issue {
+	type use struct {
+		count int
+		game  analyzer.Game // first game the name appears in
+	}
+	uses := make(map[string]*use)
+	var names []string
+	for _, g := range games {
+		for _, name := range g.Rankings {
+			u, ok := uses[name]
+			if !ok {
+				u = &use{game: g}
+				uses[name] = u
+				names = append(names, name)
+			}
+			u.count++
+		}
+	}
+	sort.Strings(names)
+
+	var issues []Issue
+	if len(roster) > 0 {
+		known := make(map[string]bool, len(roster))
+		for _, name := range roster {
+			known[name] = true
+		}
+		for _, name := range names {
+			if !known[name] {
+				g := uses[name].game
+				issues = append(issues, Issue{Severity: Error, Check: "unknown-player", GameID: g.ID, Line: g.Line,
+					Message: fmt.Sprintf("%s is not on the roster", name)})
+			}
+		}
+	}
+
+	for i, a := range names {
+		for _, b := range names[i+1:] {
+			if !similar(a, b) {
+				continue
+			}
+			// report the rarer spelling where it first appears
+			rare, common := a, b
+			if uses[a].count > uses[b].count {
+				rare, common = b, a
+			}
+			g := uses[rare].game
+			issues = append(issues, Issue{Severity: Warning, Check: "near-duplicate", GameID: g.ID, Line: g.Line,
+				Message: fmt.Sprintf("%s (%d games) looks like %s (%d games)", rare, uses[rare].count, common, uses[common].count)})
+		}
+	}
+	return issues
+}
+
+// similar reports whether two distinct names differ only by case or by a
+// single edit. Short names are only compared by case since one letter is
+// often the whole difference between real names.
+func similar(a, b string) bool {
+	la, lb := strings.ToLower(a), strings.ToLower(b)
+	if la == lb {
+		return true
+	}
+	if len([]rune(la)) < 4 || len([]rune(lb)) < 4 {
+		return false
+	}
+	return editDistance(la, lb) <= 1
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+// Count returns how many issues have each severity.
+func Count(issues []Issue) map[Severity]int {
+	counts := make(map[Severity]int)
+	for _, i := range issues {
+		counts[i.Severity]++
+	}
+	return counts
+}
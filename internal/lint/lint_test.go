@@ -0,0 +1,41 @@
+package lint
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dylanlott/guildmaster/internal/analyzer"
+)
+
+func TestCheck(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2020, 1, d, 0, 0, 0, 0, time.UTC) }
+	games := []analyzer.Game{
+		{ID: "1", Line: 1, Timestamp: day(2), Date: "1/2/2020", Rankings: []string{"Marshall", "Marshall"}},
+		{ID: "2", Line: 2, Timestamp: day(1), Date: "1/1/2020", Rankings: []string{"Dylan"}},
+		{ID: "3", Line: 3, Timestamp: day(3), Rankings: []string{"Marshal", "Dylan/Sara"}, Notes: "Dylan", TrailingNotes: true},
+		{ID: "4", Line: 4, Timestamp: day(4), Rankings: []string{"Marshall", "Dylan"}, Notes: "fun game", TrailingNotes: true},
+		// notes from a notes column are only checked for player names
+		{ID: "5", Line: 5, Timestamp: day(5), Rankings: []string{"Marshall", "Dylan"}, Notes: "close game"},
+		{ID: "6", Line: 6, Timestamp: day(6), Rankings: []string{"Marshall", "Dylan"}, Notes: "Dylan, Marshall"},
+	}
+
+	checks := make(map[string]int)
+	for _, i := range Check(games, nil, Options{Players: []string{"Marshall", "Dylan"}}) {
+		checks[i.Check]++
+	}
+	want := map[string]int{
+		"duplicate-player": 1,
+		"single-player":    1,
+		"date-order":       1,
+		"near-duplicate":   1,
+		"team-marker":      1,
+		"trailing-columns": 2,
+		"notes-players":    2,
+		"unknown-player":   2, // Marshal and Dylan/Sara
+	}
+	for check, n := range want {
+		if checks[check] != n {
+			t.Errorf("%s: expected %d issues, got %d (all: %v)", check, n, checks[check], checks)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+// Package schedule runs a task periodically, backing off while it fails.
+package schedule
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultMaxBackoff caps how long a Scheduler waits after failures when
+// MaxBackoff isn't set.
+const DefaultMaxBackoff = time.Hour
+
+// Scheduler runs a task every Interval. After a failure it waits twice as
+// long as the time before, up to MaxBackoff, and goes back to Interval once
+// the task succeeds.
+type Scheduler struct {
+	Interval time.Duration
+	// MaxBackoff caps the wait after failures; 0 uses DefaultMaxBackoff, or
+	// Interval if that is longer.
+	MaxBackoff time.Duration
+
+	mu       sync.Mutex
+	next     time.Time
+	failures int
+}
+
+// Delay returns how long to wait before the next run after the given number
+// of consecutive failures.
+func (s *Scheduler) Delay(failures int) time.Duration {
+	limit := s.MaxBackoff
+	if limit == 0 {
+		limit = max(DefaultMaxBackoff, s.Interval)
+	}
+	d := s.Interval
+	for range failures {
+		if d >= limit/2 {
+			return limit
+		}
+		d *= 2
+	}
+	return min(d, limit)
+}
+
+// Run calls task every Interval, starting one Interval from now, until ctx
+// is done. Each call gets a context that is canceled when ctx is done or
+// when it runs for longer than Interval, so a hung call can't hold up the
+// ones after it.
+func (s *Scheduler) Run(ctx context.Context, task func(context.Context) error) {
+	timer := time.NewTimer(s.schedule(s.Delay(0)))
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+		runCtx, cancel := context.WithTimeout(ctx, s.Interval)
+		err := task(runCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+		s.mu.Lock()
+		if err != nil {
+			s.failures++
+		} else {
+			s.failures = 0
+		}
+		failures := s.failures
+		s.mu.Unlock()
+		timer.Reset(s.schedule(s.Delay(failures)))
+	}
+}
+
+// schedule records that the next run is d from now and returns d.
+func (s *Scheduler) schedule(d time.Duration) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.next = time.Now().Add(d)
+	return d
+}
+
+// Next returns when the task is next due, or the zero time before Run starts.
+func (s *Scheduler) Next() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.next
+}
+
+// Failures returns how many runs in a row have failed.
+func (s *Scheduler) Failures() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.failures
+}
@@ -0,0 +1,101 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDelay(t *testing.T) {
+	s := &Scheduler{Interval: time.Minute, MaxBackoff: 10 * time.Minute}
+	want := []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 10 * time.Minute, 10 * time.Minute}
+	for failures, w := range want {
+		if got := s.Delay(failures); got != w {
+			t.Errorf("Delay(%d) = %v, want %v", failures, got, w)
+		}
+	}
+	if got := s.Delay(1000); got != 10*time.Minute {
+		t.Errorf("Delay(1000) = %v, want the cap", got)
+	}
+
+	s = &Scheduler{Interval: 2 * time.Hour}
+	if got := s.Delay(3); got != 2*time.Hour {
+		t.Errorf("Delay with an interval over the default cap = %v, want the interval", got)
+	}
+}
+
+func TestRunBacksOffAndRecovers(t *testing.T) {
+	s := &Scheduler{Interval: 10 * time.Millisecond, MaxBackoff: 40 * time.Millisecond}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		mu    sync.Mutex
+		times []time.Time
+	)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run(ctx, func(context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			times = append(times, time.Now())
+			if len(times) <= 3 {
+				return errors.New("source unreachable")
+			}
+			if len(times) == 5 {
+				cancel()
+			}
+			return nil
+		})
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run didn't stop when its context was canceled")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(times) != 5 {
+		t.Fatalf("task ran %d times, want 5", len(times))
+	}
+	// the waits after the 3 failures grow: 20ms, 40ms, then capped at 40ms
+	if gap := times[3].Sub(times[2]); gap < 40*time.Millisecond {
+		t.Errorf("waited %v after 3 failures, want at least 40ms", gap)
+	}
+	if gap := times[1].Sub(times[0]); gap < 20*time.Millisecond {
+		t.Errorf("waited %v after the first failure, want at least 20ms", gap)
+	}
+	if s.Failures() != 0 {
+		t.Errorf("Failures = %d after a success, want 0", s.Failures())
+	}
+}
+
+func TestRunCancelsTask(t *testing.T) {
+	s := &Scheduler{Interval: 10 * time.Millisecond}
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	var once sync.Once
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run(ctx, func(ctx context.Context) error {
+			once.Do(func() { close(started) })
+			<-ctx.Done()
+			return ctx.Err()
+		})
+	}()
+	<-started
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run didn't return after its context was canceled mid-task")
+	}
+	if s.Next().IsZero() {
+		t.Error("Next not set once Run started")
+	}
+}
@@ -0,0 +1,310 @@
+package scoring
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dylanlott/guildmaster/internal/analyzer"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Bucket names in the bolt database.
+var (
+	metaBucket    = []byte("meta")
+	playersBucket = []byte("players")
+	gamesBucket   = []byte("games")
+	eventsBucket  = []byte("events")
+	snapBucket    = []byte("snapshots")
+
+	schemaVersionKey = []byte("schema_version")
+	latestSnapKey    = []byte("latest")
+)
+
+// migrations upgrade the database one schema version at a time:
+// migrations[i] moves a database at version i to version i+1. Append new
+// migrations; never edit ones that have shipped.
+var migrations = []func(tx *bolt.Tx) error{
+	// 1: players by name and games in the order they were added
+	func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(playersBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(gamesBucket)
+		return err
+	},
+	// 2: the game journal and its snapshots
+	func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(eventsBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(snapBucket)
+		return err
+	},
+}
+
+// SchemaVersion is the database version this build writes.
+var SchemaVersion = len(migrations)
+
+// playerRecord is a player as stored in the players bucket.
+type playerRecord struct {
+	Name      string    `json:"name"`
+	Rating    int       `json:"rating"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// BoltStore is a Storage and Journal persisted to a bbolt database file. Everything is
+// also kept in memory so reads don't touch the disk; writes reach the file
+// before they are visible.
+type BoltStore struct {
+	db *bolt.DB
+
+	mu     sync.RWMutex
+	scores map[string]int
+	games  []analyzer.Game
+}
+
+var (
+	_ Storage = (*BoltStore)(nil)
+	_ Journal = (*BoltStore)(nil)
+)
+
+// OpenBolt opens or creates the database at path, migrating it to the
+// current schema.
+func OpenBolt(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	s := &BoltStore{db: db, scores: make(map[string]int)}
+	if err := s.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := s.load(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// migrate runs every migration newer than the database's schema version.
+func (s *BoltStore) migrate() error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		meta, err := tx.CreateBucketIfNotExists(metaBucket)
+		if err != nil {
+			return err
+		}
+		version := 0
+		if v := meta.Get(schemaVersionKey); v != nil {
+			if version, err = strconv.Atoi(string(v)); err != nil {
+				return fmt.Errorf("invalid schema version %q: %w", v, err)
+			}
+		}
+		if version > len(migrations) {
+			return fmt.Errorf("database schema version %d is newer than this build supports (%d)", version, len(migrations))
+		}
+		for ; version < len(migrations); version++ {
+			if err := migrations[version](tx); err != nil {
+				return fmt.Errorf("migration to schema version %d failed: %w", version+1, err)
+			}
+		}
+		return meta.Put(schemaVersionKey, []byte(strconv.Itoa(version)))
+	})
+}
+
+// load reads every player and game into memory.
+func (s *BoltStore) load() error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		err := tx.Bucket(playersBucket).ForEach(func(k, v []byte) error {
+			var p playerRecord
+			if err := json.Unmarshal(v, &p); err != nil {
+				return fmt.Errorf("player %s: %w", k, err)
+			}
+			s.scores[p.Name] = p.Rating
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(gamesBucket).ForEach(func(k, v []byte) error {
+			var g analyzer.Game
+			if err := json.Unmarshal(v, &g); err != nil {
+				return fmt.Errorf("game %d: %w", binary.BigEndian.Uint64(k), err)
+			}
+			s.games = append(s.games, g)
+			return nil
+		})
+	})
+}
+
+// GetAll returns a copy of all scores.
+func (s *BoltStore) GetAll() map[string]int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return maps.Clone(s.scores)
+}
+
+// ReplaceAll replaces every score with the provided snapshot.
+func (s *BoltStore) ReplaceAll(scores map[string]int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now().UTC()
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket(playersBucket); err != nil {
+			return err
+		}
+		b, err := tx.CreateBucket(playersBucket)
+		if err != nil {
+			return err
+		}
+		for name, rating := range scores {
+			data, err := json.Marshal(playerRecord{Name: name, Rating: rating, UpdatedAt: now})
+			if err != nil {
+				return err
+			}
+			if err := b.Put([]byte(name), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save scores: %w", err)
+	}
+	s.scores = maps.Clone(scores)
+	return nil
+}
+
+// Games returns a copy of the stored games in the order they were added.
+func (s *BoltStore) Games() []analyzer.Game {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.games)
+}
+
+// AddGame stores a game after the existing ones.
+func (s *BoltStore) AddGame(g analyzer.Game) error {
+	return s.writeGames(false, []analyzer.Game{g})
+}
+
+// ReplaceGames replaces every stored game.
+func (s *BoltStore) ReplaceGames(games []analyzer.Game) error {
+	return s.writeGames(true, games)
+}
+
+// writeGames appends games, first removing the existing ones when replace is set.
+func (s *BoltStore) writeGames(replace bool, games []analyzer.Game) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		if replace {
+			if err := tx.DeleteBucket(gamesBucket); err != nil {
+				return err
+			}
+			if _, err := tx.CreateBucket(gamesBucket); err != nil {
+				return err
+			}
+		}
+		b := tx.Bucket(gamesBucket)
+		for _, g := range games {
+			seq, err := b.NextSequence()
+			if err != nil {
+				return err
+			}
+			data, err := json.Marshal(g)
+			if err != nil {
+				return err
+			}
+			if err := b.Put(binary.BigEndian.AppendUint64(nil, seq), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save games: %w", err)
+	}
+	if replace {
+		s.games = nil
+	}
+	s.games = append(s.games, games...)
+	return nil
+}
+
+// Append adds e to the journal with the next sequence number.
+func (s *BoltStore) Append(e Event) (Event, error) {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(eventsBucket)
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		e.Seq = seq
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		return b.Put(binary.BigEndian.AppendUint64(nil, seq), data)
+	})
+	if err != nil {
+		return Event{}, fmt.Errorf("failed to append event: %w", err)
+	}
+	return e, nil
+}
+
+// Events returns the journaled events after seq.
+func (s *BoltStore) Events(after uint64) ([]Event, error) {
+	var events []Event
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(eventsBucket).Cursor()
+		for k, v := c.Seek(binary.BigEndian.AppendUint64(nil, after+1)); k != nil; k, v = c.Next() {
+			var e Event
+			if err := json.Unmarshal(v, &e); err != nil {
+				return fmt.Errorf("event %d: %w", binary.BigEndian.Uint64(k), err)
+			}
+			events = append(events, e)
+		}
+		return nil
+	})
+	return events, err
+}
+
+// SaveSnapshot replaces the latest snapshot.
+func (s *BoltStore) SaveSnapshot(snap Snapshot) error {
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(snapBucket).Put(latestSnapKey, data)
+	})
+}
+
+// LatestSnapshot returns the latest snapshot.
+func (s *BoltStore) LatestSnapshot() (Snapshot, bool, error) {
+	var snap Snapshot
+	var ok bool
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(snapBucket).Get(latestSnapKey)
+		if data == nil {
+			return nil
+		}
+		ok = true
+		return json.Unmarshal(data, &snap)
+	})
+	if err != nil {
+		return Snapshot{}, false, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	return snap, ok, nil
+}
+
+// Close closes the database file.
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,98 @@
+package scoring
+
+import (
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/dylanlott/guildmaster/internal/analyzer"
+	bolt "go.etcd.io/bbolt"
+)
+
+// testStorage exercises the behavior every Storage shares.
+func testStorage(t *testing.T, s Storage) {
+	t.Helper()
+	if err := s.ReplaceAll(map[string]int{"A": 1520, "B": 1480, "C": 1500}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ReplaceAll(map[string]int{"A": 1520, "B": 1480}); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"A": 1520, "B": 1480}
+	if got := s.GetAll(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected scores %v, got %v", want, got)
+	}
+
+	games := []analyzer.Game{{ID: "1", Rankings: []string{"A", "B"}}, {ID: "2", Rankings: []string{"B", "A"}}}
+	if err := s.ReplaceGames(games); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddGame(analyzer.Game{ID: "3", Rankings: []string{"D", "A"}}); err != nil {
+		t.Fatal(err)
+	}
+	got := s.Games()
+	if len(got) != 3 || got[0].ID != "1" || got[2].ID != "3" {
+		t.Fatalf("unexpected games %+v", got)
+	}
+}
+
+func TestStore(t *testing.T) {
+	testStorage(t, NewStore())
+}
+
+func TestBoltStorePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "guildmaster.db")
+	s, err := OpenBolt(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStorage(t, s)
+	scores, games := s.GetAll(), s.Games()
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := OpenBolt(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	if got := reopened.GetAll(); !reflect.DeepEqual(got, scores) {
+		t.Fatalf("scores after reopening: got %v, want %v", got, scores)
+	}
+	if got := reopened.Games(); !reflect.DeepEqual(got, games) {
+		t.Fatalf("games after reopening: got %+v, want %+v", got, games)
+	}
+}
+
+func TestBoltStoreMigrations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "guildmaster.db")
+	s, err := OpenBolt(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	version := func() string {
+		var v string
+		s.db.View(func(tx *bolt.Tx) error {
+			v = string(tx.Bucket(metaBucket).Get(schemaVersionKey))
+			return nil
+		})
+		return v
+	}
+	if got := version(); got != strconv.Itoa(SchemaVersion) {
+		t.Fatalf("new database at schema version %s, want %d", got, SchemaVersion)
+	}
+
+	// a database written by a newer build must not be opened
+	if err := s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(metaBucket).Put(schemaVersionKey, []byte(strconv.Itoa(SchemaVersion+1)))
+	}); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+	if s, err := OpenBolt(path); err == nil {
+		s.Close()
+		t.Fatal("expected an error opening a database from a newer schema")
+	}
+}
@@ -0,0 +1,670 @@
+package scoring
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dylanlott/guildmaster/internal/analyzer"
+)
+
+// EventType is what happened to a game.
+type EventType string
+
+const (
+	// Recorded adds a game.
+	Recorded EventType = "recorded"
+	// Corrected replaces a recorded game with the same ID.
+	Corrected EventType = "corrected"
+	// Voided removes a recorded game.
+	Voided EventType = "voided"
+	// Confirmed records a player's or scorekeeper's confirmation of a pending
+	// game, which is scored once its status is confirmed.
+	Confirmed EventType = "confirmed"
+	// Rejected records that a pending game was disputed.
+	Rejected EventType = "rejected"
+)
+
+// Event is one entry in the game journal.
+type Event struct {
+	// Seq numbers events from 1 in the order they were appended.
+	Seq  uint64        `json:"seq"`
+	Type EventType     `json:"type"`
+	At   time.Time     `json:"at"`
+	Game analyzer.Game `json:"game"` // only the ID is set for Voided
+	// Manual is set for edits made directly, rather than picked up from the game source by Sync.
+	Manual bool `json:"manual,omitempty"`
+	// By names who confirmed or rejected the game.
+	By string `json:"by,omitempty"`
+}
+
+// Snapshot is the state after applying every event up to and including Seq.
+type Snapshot struct {
+	Seq    uint64          `json:"seq"`
+	Games  []analyzer.Game `json:"games"`
+	Scores map[string]int  `json:"scores"`
+	// Synced is the game source as of the last Sync.
+	Synced []analyzer.Game `json:"synced,omitempty"`
+}
+
+// Journal is an append-only log of game events, plus the latest snapshot of
+// the state they produce.
+type Journal interface {
+	// Append stores e with the next sequence number and returns it.
+	Append(e Event) (Event, error)
+	// Events returns the events after seq, oldest first.
+	Events(after uint64) ([]Event, error)
+	// SaveSnapshot replaces the latest snapshot.
+	SaveSnapshot(s Snapshot) error
+	// LatestSnapshot returns the latest snapshot, or false when there is none.
+	LatestSnapshot() (Snapshot, bool, error)
+}
+
+// MemoryJournal is a Journal kept in memory.
+type MemoryJournal struct {
+	mu       sync.Mutex
+	events   []Event
+	snapshot *Snapshot
+}
+
+var _ Journal = (*MemoryJournal)(nil)
+
+// NewMemoryJournal creates an empty in-memory journal.
+func NewMemoryJournal() *MemoryJournal {
+	return &MemoryJournal{}
+}
+
+// Append stores e with the next sequence number.
+func (j *MemoryJournal) Append(e Event) (Event, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	e.Seq = uint64(len(j.events)) + 1
+	j.events = append(j.events, e)
+	return e, nil
+}
+
+// Events returns the events after seq.
+func (j *MemoryJournal) Events(after uint64) ([]Event, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if after >= uint64(len(j.events)) {
+		return nil, nil
+	}
+	return slices.Clone(j.events[after:]), nil
+}
+
+// SaveSnapshot replaces the latest snapshot.
+func (j *MemoryJournal) SaveSnapshot(s Snapshot) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.snapshot = &s
+	return nil
+}
+
+// LatestSnapshot returns the latest snapshot.
+func (j *MemoryJournal) LatestSnapshot() (Snapshot, bool, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.snapshot == nil {
+		return Snapshot{}, false, nil
+	}
+	return *j.snapshot, true, nil
+}
+
+// DefaultSnapshotEvery is how many events a Ledger appends between snapshots.
+const DefaultSnapshotEvery = 100
+
+// Ledger records game events in a journal and keeps a Storage in step with
+// them: the stored games and scores are always the result of replaying the
+// journal, never edited directly.
+type Ledger struct {
+	journal Journal
+	store   Storage
+	rate    func([]analyzer.Game) (map[string]int, error)
+	// SnapshotEvery is how many events are appended between snapshots; 0 uses DefaultSnapshotEvery.
+	SnapshotEvery int
+	// Quorum is how many of its players must confirm a pending game before
+	// it is scored; 0 means more than half of them.
+	Quorum int
+	// OnChange, when set, is called with the events appended by each change
+	// to the games and how the ratings changed, after the store is updated.
+	// It is not called while replaying the journal.
+	OnChange func(events []Event, changes []RatingChange)
+
+	mu      sync.Mutex
+	games   []analyzer.Game          // current games in chronological order, scored or not
+	synced  map[string]analyzer.Game // the game source as of the last Sync, by ID
+	seq     uint64                   // last applied event
+	snapSeq uint64                   // event of the latest snapshot
+}
+
+// NewLedger rebuilds store from the journal: it starts from the latest
+// snapshot, replays the events after it and rates the resulting games. rate
+// computes the scores for a list of games; nil rates them with
+// analyzer.RateGames.
+func NewLedger(journal Journal, store Storage, rate func([]analyzer.Game) (map[string]int, error)) (*Ledger, error) {
+	if rate == nil {
+		rate = func(games []analyzer.Game) (map[string]int, error) {
+			return analyzer.RateGames(analyzer.InitializeElo(), games, nil)
+		}
+	}
+	l := &Ledger{journal: journal, store: store, rate: rate, synced: make(map[string]analyzer.Game)}
+
+	snap, ok, err := journal.LatestSnapshot()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	if ok {
+		l.games, l.seq, l.snapSeq = snap.Games, snap.Seq, snap.Seq
+		for _, g := range snap.Synced {
+			l.synced[g.ID] = g
+		}
+	}
+	events, err := journal.Events(l.seq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+	for _, e := range events {
+		l.apply(e)
+	}
+	// the games are always rated again rather than taking the snapshot's
+	// scores, which were rated under whatever settings the server had then
+	_, err = l.commit()
+	return l, err
+}
+
+// apply applies e to the ledger's games and, unless it was a manual edit, to
+// its copy of the game source.
+func (l *Ledger) apply(e Event) {
+	l.games = apply(l.games, e)
+	l.seq = e.Seq
+	if e.Manual {
+		return
+	}
+	if e.Type == Voided {
+		delete(l.synced, e.Game.ID)
+	} else {
+		l.synced[e.Game.ID] = e.Game
+	}
+}
+
+// apply returns games after e, in chronological order.
+func apply(games []analyzer.Game, e Event) []analyzer.Game {
+	i := findGame(games, e.Game.ID)
+	switch {
+	case e.Type == Voided && i >= 0:
+		games = slices.Delete(slices.Clone(games), i, i+1)
+	case e.Type == Voided:
+	case i < 0:
+		games = append(slices.Clone(games), e.Game)
+	default:
+		games = slices.Clone(games)
+		games[i] = e.Game
+	}
+	analyzer.SortChronologically(games)
+	return games
+}
+
+// commit rates the scored games into the store, returning how the ratings
+// changed, and takes a snapshot when enough events have been applied since
+// the last one. The caller holds l.mu or has exclusive access.
+func (l *Ledger) commit() ([]RatingChange, error) {
+	scored := scoredGames(l.games)
+	scores, err := l.rate(scored)
+	if err != nil {
+		return nil, err
+	}
+	before := l.store.GetAll()
+	if err := l.store.ReplaceGames(scored); err != nil {
+		return nil, err
+	}
+	if err := l.store.ReplaceAll(scores); err != nil {
+		return nil, err
+	}
+	changes := DiffScores(before, scores)
+	every := uint64(l.SnapshotEvery)
+	if every == 0 {
+		every = DefaultSnapshotEvery
+	}
+	if l.seq-l.snapSeq >= every {
+		snap := Snapshot{Seq: l.seq, Games: l.games, Scores: maps.Clone(scores)}
+		for _, g := range l.synced {
+			snap.Synced = append(snap.Synced, g)
+		}
+		analyzer.SortChronologically(snap.Synced)
+		if err := l.journal.SaveSnapshot(snap); err != nil {
+			return changes, fmt.Errorf("failed to save snapshot: %w", err)
+		}
+		l.snapSeq = l.seq
+	}
+	return changes, nil
+}
+
+// update journals the events returned by fn, applies them and updates the
+// store, returning how the ratings changed. fn runs under the ledger's lock
+// and sees its current games; OnChange is called once the lock is released.
+func (l *Ledger) update(fn func(games []analyzer.Game) ([]Event, error)) ([]RatingChange, error) {
+	events, changes, err := l.updateLocked(fn)
+	if len(events) > 0 && err == nil && l.OnChange != nil {
+		l.OnChange(events, changes)
+	}
+	return changes, err
+}
+
+func (l *Ledger) updateLocked(fn func(games []analyzer.Game) ([]Event, error)) ([]Event, []RatingChange, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	events, err := fn(l.games)
+	if err != nil || len(events) == 0 {
+		return nil, nil, err
+	}
+	now := time.Now().UTC()
+	for i, e := range events {
+		e.At = now
+		e, err := l.journal.Append(e)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to append to journal: %w", err)
+		}
+		l.apply(e)
+		events[i] = e
+	}
+	changes, err := l.commit()
+	return events, changes, err
+}
+
+// scoredGames returns the games that count towards the ratings.
+func scoredGames(games []analyzer.Game) []analyzer.Game {
+	return slices.DeleteFunc(slices.Clone(games), func(g analyzer.Game) bool { return !g.Scored() })
+}
+
+// findGame returns the index of the game with the given ID, or -1.
+func findGame(games []analyzer.Game, id string) int {
+	return slices.IndexFunc(games, func(g analyzer.Game) bool { return g.ID == id })
+}
+
+// Record adds a new game and returns it. Its ID must not already be
+// recorded; a game without one is given "j" followed by the sequence number
+// of the event that records it. A pending game is confirmed straight away if
+// its ConfirmedBy already makes a quorum.
+func (l *Ledger) Record(g analyzer.Game) (analyzer.Game, []RatingChange, error) {
+	changes, err := l.update(func(games []analyzer.Game) ([]Event, error) {
+		if g.ID == "" {
+			g.ID = "j" + strconv.FormatUint(l.seq+1, 10)
+		}
+		if findGame(games, g.ID) >= 0 {
+			return nil, fmt.Errorf("game %s: %w", g.ID, ErrGameExists)
+		}
+		if g.Status == analyzer.Pending && confirmations(g) >= l.ConfirmationsNeeded(g) {
+			g.Status = analyzer.Confirmed
+		}
+		return []Event{{Type: Recorded, Game: g, Manual: true}}, nil
+	})
+	return g, changes, err
+}
+
+// ConfirmationsNeeded returns how many of g's players must confirm it.
+func (l *Ledger) ConfirmationsNeeded(g analyzer.Game) int {
+	n := len(g.Players())
+	if l.Quorum <= 0 {
+		return min(n/2+1, n)
+	}
+	// a quorum larger than the game needs every player
+	return min(l.Quorum, n)
+}
+
+// confirmations counts the players of g who have confirmed it.
+func confirmations(g analyzer.Game) int {
+	players := g.Players()
+	n := 0
+	for _, by := range g.ConfirmedBy {
+		if slices.Contains(players, by) {
+			n++
+		}
+	}
+	return n
+}
+
+// Confirm records that by confirms a pending game and returns the game. by
+// must have played in it unless scorekeeper is set, in which case the game
+// is confirmed outright, even if it was rejected; otherwise it is confirmed
+// once a quorum of its players have. Confirming a game that is already
+// scored changes nothing.
+func (l *Ledger) Confirm(id, by string, scorekeeper bool) (analyzer.Game, []RatingChange, error) {
+	var g analyzer.Game
+	changes, err := l.update(func(games []analyzer.Game) ([]Event, error) {
+		i := findGame(games, id)
+		if i < 0 {
+			return nil, fmt.Errorf("game %s: %w", id, ErrNoGame)
+		}
+		g = games[i]
+		player := slices.Contains(g.Players(), by)
+		switch {
+		case g.Scored():
+			return nil, nil
+		case scorekeeper:
+			g.ApprovedBy, g.Status = by, analyzer.Confirmed
+		case !player:
+			return nil, fmt.Errorf("%s: %w %s", by, ErrNotPlayer, id)
+		case g.Status != analyzer.Pending:
+			return nil, fmt.Errorf("game %s: %w", id, ErrNotPending)
+		case slices.Contains(g.ConfirmedBy, by):
+			return nil, nil
+		}
+		if player && !slices.Contains(g.ConfirmedBy, by) {
+			g.ConfirmedBy = append(slices.Clone(g.ConfirmedBy), by)
+		}
+		if confirmations(g) >= l.ConfirmationsNeeded(g) {
+			g.Status = analyzer.Confirmed
+		}
+		return []Event{{Type: Confirmed, Game: g, Manual: true, By: by}}, nil
+	})
+	return g, changes, err
+}
+
+// Reject records that by disputes a pending game, so it isn't scored unless a
+// scorekeeper confirms it later. by must have played in it unless
+// scorekeeper is set.
+func (l *Ledger) Reject(id, by string, scorekeeper bool) (analyzer.Game, error) {
+	var g analyzer.Game
+	_, err := l.update(func(games []analyzer.Game) ([]Event, error) {
+		i := findGame(games, id)
+		if i < 0 {
+			return nil, fmt.Errorf("game %s: %w", id, ErrNoGame)
+		}
+		g = games[i]
+		switch {
+		case !scorekeeper && !slices.Contains(g.Players(), by):
+			return nil, fmt.Errorf("%s: %w %s", by, ErrNotPlayer, id)
+		case g.Status == analyzer.Rejected:
+			return nil, nil
+		case g.Status != analyzer.Pending:
+			return nil, fmt.Errorf("game %s: %w", id, ErrNotPending)
+		}
+		g.Status, g.RejectedBy = analyzer.Rejected, by
+		return []Event{{Type: Rejected, Game: g, Manual: true, By: by}}, nil
+	})
+	return g, err
+}
+
+// Correct replaces a recorded game with g, matched by ID. Changing its
+// timestamp moves it, and every game is re-rated in the new order.
+func (l *Ledger) Correct(g analyzer.Game) ([]RatingChange, error) {
+	return l.update(func(games []analyzer.Game) ([]Event, error) {
+		if findGame(games, g.ID) < 0 {
+			return nil, fmt.Errorf("game %s: %w", g.ID, ErrNoGame)
+		}
+		return []Event{{Type: Corrected, Game: g, Manual: true}}, nil
+	})
+}
+
+// Edit corrects a recorded game by calling fn on a copy of it, under the
+// ledger's lock so no other change to the game lands in between, and returns
+// the game as edited. Nothing is journaled when fn fails or changes nothing.
+// A pending game loses the confirmations of players the edit removed.
+func (l *Ledger) Edit(id string, fn func(*analyzer.Game) error) (analyzer.Game, []RatingChange, error) {
+	var g analyzer.Game
+	changes, err := l.update(func(games []analyzer.Game) ([]Event, error) {
+		i := findGame(games, id)
+		if i < 0 {
+			return nil, fmt.Errorf("game %s: %w", id, ErrNoGame)
+		}
+		g = games[i]
+		if err := fn(&g); err != nil {
+			return nil, err
+		}
+		g.ID = id
+		if g.Status == analyzer.Pending {
+			players := g.Players()
+			g.ConfirmedBy = slices.DeleteFunc(slices.Clone(g.ConfirmedBy), func(by string) bool {
+				return !slices.Contains(players, by)
+			})
+		}
+		if sameGame(g, games[i]) {
+			return nil, nil
+		}
+		return []Event{{Type: Corrected, Game: g, Manual: true}}, nil
+	})
+	return g, changes, err
+}
+
+// Void removes a recorded game.
+func (l *Ledger) Void(id string) ([]RatingChange, error) {
+	return l.update(func(games []analyzer.Game) ([]Event, error) {
+		if findGame(games, id) < 0 {
+			return nil, fmt.Errorf("game %s: %w", id, ErrNoGame)
+		}
+		return []Event{{Type: Voided, Game: analyzer.Game{ID: id}, Manual: true}}, nil
+	})
+}
+
+var (
+	// ErrNoGame is returned when editing a game that isn't recorded.
+	ErrNoGame = errors.New("no such game")
+	// ErrGameExists is returned when recording a game whose ID is taken.
+	ErrGameExists = errors.New("game is already recorded")
+	// ErrNotPlayer is returned when someone who didn't play in a game confirms or rejects it.
+	ErrNotPlayer = errors.New("didn't play in game")
+	// ErrNotPending is returned when a player confirms a rejected game or anyone rejects a scored one.
+	ErrNotPending = errors.New("game isn't pending confirmation")
+)
+
+// Sync journals what changed in the game source since the last Sync, given
+// all of its games: new IDs are recorded, changed games are corrected and
+// missing ones voided. Games the source hasn't changed keep any manual edits.
+// Game IDs must be unique. Games identified only by their line (LineID) are
+// journaled under a content ID instead; see StableID. Such a game with the
+// same date and finishing order as a confirmed game recorded through the API
+// is taken to be that game written back to the source, and keeps its ID and
+// confirmations.
+func (l *Ledger) Sync(games []analyzer.Game) ([]RatingChange, error) {
+	games = stableIDs(games)
+	return l.update(func(recorded []analyzer.Game) ([]Event, error) {
+		current := make(map[string]analyzer.Game, len(recorded))
+		for _, g := range recorded {
+			current[g.ID] = g
+		}
+		games := l.matchWritten(games, recorded)
+		seen := make(map[string]bool, len(games))
+		var events []Event
+		for _, g := range games {
+			if seen[g.ID] {
+				return nil, fmt.Errorf("game ID %s is used more than once", g.ID)
+			}
+			seen[g.ID] = true
+			cur, ok := current[g.ID]
+			if ok && cur.Status == analyzer.Confirmed && g.Status == "" {
+				// the game log has no confirmations, so keep the ones from the API
+				g.Status, g.ConfirmedBy, g.ApprovedBy = cur.Status, cur.ConfirmedBy, cur.ApprovedBy
+			}
+			if prev, ok := l.synced[g.ID]; ok && sameGame(prev, g) {
+				continue
+			}
+			_, known := l.synced[g.ID]
+			switch {
+			case ok && sameGame(cur, g) && known:
+				l.synced[g.ID] = g
+			case ok:
+				// also journal a game recorded through the API turning up in
+				// the source, so its copy there survives a restart and later
+				// edits aren't taken for changes to the source
+				events = append(events, Event{Type: Corrected, Game: g})
+			default:
+				events = append(events, Event{Type: Recorded, Game: g})
+			}
+		}
+		// void in a fixed order so the journal doesn't depend on map order
+		for _, id := range slices.Sorted(maps.Keys(l.synced)) {
+			if seen[id] {
+				continue
+			}
+			if _, ok := current[id]; ok {
+				events = append(events, Event{Type: Voided, Game: analyzer.Game{ID: id}})
+			} else {
+				delete(l.synced, id)
+			}
+		}
+		return events, nil
+	})
+}
+
+// matchWritten gives the game source's rows without an ID of their own the ID
+// of the confirmed game recorded through the API that they are a copy of, so
+// a game written back to the source isn't recorded a second time. A new row
+// matches a game with the same date and finishing order that no other source
+// game has claimed; once synced, it's compared with the row as last synced,
+// so editing the game doesn't break the match.
+func (l *Ledger) matchWritten(games, recorded []analyzer.Game) []analyzer.Game {
+	claimed := make(map[string]bool, len(games))
+	for _, g := range games {
+		claimed[g.ID] = true
+	}
+	out := slices.Clone(games)
+	for i, g := range out {
+		if _, ok := l.synced[g.ID]; !g.LineID || ok {
+			continue
+		}
+		for _, cur := range recorded {
+			if claimed[cur.ID] || cur.Status != analyzer.Confirmed {
+				continue
+			}
+			written, ok := l.synced[cur.ID]
+			if !ok {
+				written = cur
+			}
+			if written.Date == g.Date && slices.Equal(written.Rankings, g.Rankings) {
+				out[i].ID = cur.ID
+				claimed[cur.ID] = true
+				break
+			}
+		}
+	}
+	return out
+}
+
+// StableID returns the ID a game without one of its own is journaled under:
+// "g" and a hash of its date and finishing order, so it stays the same when
+// rows are inserted or deleted around it. The nth copy of an identical game
+// gets "-n" appended.
+func StableID(g analyzer.Game, n int) string {
+	sum := sha256.Sum256([]byte(g.Date + "\x00" + strings.Join(g.Rankings, "\x00")))
+	id := "g" + hex.EncodeToString(sum[:5])
+	if n > 1 {
+		id += "-" + strconv.Itoa(n)
+	}
+	return id
+}
+
+// stableIDs returns games with the LineID ones given their StableID, numbering
+// identical games in order.
+func stableIDs(games []analyzer.Game) []analyzer.Game {
+	out := slices.Clone(games)
+	seen := make(map[string]int)
+	for i, g := range out {
+		if !g.LineID {
+			continue
+		}
+		base := StableID(g, 1)
+		seen[base]++
+		out[i].ID = StableID(g, seen[base])
+	}
+	return out
+}
+
+// sameGame reports whether a and b would be journaled identically. Line is
+// ignored since it moves when rows are inserted above a game.
+func sameGame(a, b analyzer.Game) bool {
+	a.Line, b.Line = 0, 0
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(ja, jb)
+}
+
+// Game returns the recorded game with the given ID.
+func (l *Ledger) Game(id string) (analyzer.Game, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	i := findGame(l.games, id)
+	if i < 0 {
+		return analyzer.Game{}, false
+	}
+	return l.games[i], true
+}
+
+// Games returns the scored games in chronological order.
+func (l *Ledger) Games() []analyzer.Game {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return scoredGames(l.games)
+}
+
+// AllGames returns every recorded game in chronological order, including
+// those pending confirmation and rejected ones.
+func (l *Ledger) AllGames() []analyzer.Game {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return slices.Clone(l.games)
+}
+
+// History returns every event for the game with the given ID, oldest first,
+// so the original entry and each edit to it can be audited.
+func (l *Ledger) History(id string) ([]Event, error) {
+	events, err := l.journal.Events(0)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(events, func(e Event) bool { return e.Game.ID != id }), nil
+}
+
+// Seq returns the sequence number of the last applied event.
+func (l *Ledger) Seq() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.seq
+}
+
+// RatingChange is how one player's rating changed.
+type RatingChange struct {
+	Player string `json:"player"`
+	// Before and After are 0 when the player had no rating.
+	Before int `json:"before"`
+	After  int `json:"after"`
+}
+
+// Delta is the change in rating.
+func (c RatingChange) Delta() int { return c.After - c.Before }
+
+// MarshalJSON includes the delta.
+func (c RatingChange) MarshalJSON() ([]byte, error) {
+	type change RatingChange
+	return json.Marshal(struct {
+		change
+		Delta int `json:"delta"`
+	}{change(c), c.Delta()})
+}
+
+// DiffScores lists the players whose rating differs between before and after,
+// by name.
+func DiffScores(before, after map[string]int) []RatingChange {
+	var changes []RatingChange
+	for player, r := range after {
+		if b, ok := before[player]; !ok || b != r {
+			changes = append(changes, RatingChange{Player: player, Before: before[player], After: r})
+		}
+	}
+	for player, b := range before {
+		if _, ok := after[player]; !ok {
+			changes = append(changes, RatingChange{Player: player, Before: b})
+		}
+	}
+	slices.SortFunc(changes, func(a, b RatingChange) int { return strings.Compare(a.Player, b.Player) })
+	return changes
+}
@@ -0,0 +1,655 @@
+package scoring
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dylanlott/guildmaster/internal/analyzer"
+)
+
+func journalGame(id string, day int, rankings ...string) analyzer.Game {
+	return analyzer.Game{
+		ID:        id,
+		Timestamp: time.Date(2024, 3, day, 20, 0, 0, 0, time.UTC),
+		Rankings:  rankings,
+	}
+}
+
+func rated(t *testing.T, games ...analyzer.Game) map[string]int {
+	t.Helper()
+	scores, err := analyzer.RateGames(analyzer.InitializeElo(), games, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return scores
+}
+
+func TestLedgerRecordCorrectVoid(t *testing.T) {
+	store := NewStore()
+	l, err := NewLedger(NewMemoryJournal(), store, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g1 := journalGame("1", 1, "A", "B", "C")
+	g2 := journalGame("2", 2, "B", "A")
+	g3 := journalGame("3", 3, "C", "A")
+	for _, g := range []analyzer.Game{g2, g1, g3} {
+		if _, _, err := l.Record(g); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, _, err := l.Record(g1); !errors.Is(err, ErrGameExists) {
+		t.Fatalf("expected recording a game twice to fail, got %v", err)
+	}
+	if g, _, err := l.Record(journalGame("", 4, "A", "C")); err != nil || g.ID != "j4" {
+		t.Fatalf("expected a game without an ID to be recorded as j4, got %q, %v", g.ID, err)
+	}
+	if _, err := l.Void("j4"); err != nil {
+		t.Fatal(err)
+	}
+	if got := store.GetAll(); !reflect.DeepEqual(got, rated(t, g1, g2, g3)) {
+		t.Fatalf("scores after recording out of order: got %v, want %v", got, rated(t, g1, g2, g3))
+	}
+
+	g2.Rankings = []string{"A", "B"}
+	if _, err := l.Correct(g2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Void("3"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Void("3"); err == nil {
+		t.Fatal("expected voiding a missing game to fail")
+	}
+	if got, want := store.GetAll(), rated(t, g1, g2); !reflect.DeepEqual(got, want) {
+		t.Fatalf("scores after correcting and voiding: got %v, want %v", got, want)
+	}
+	if got := store.Games(); len(got) != 2 || got[0].ID != "1" || got[1].Rankings[0] != "A" {
+		t.Fatalf("unexpected games %+v", got)
+	}
+	if l.Seq() != 7 {
+		t.Fatalf("expected 7 events, got %d", l.Seq())
+	}
+}
+
+func TestLedgerSync(t *testing.T) {
+	journal := NewMemoryJournal()
+	l, err := NewLedger(journal, NewStore(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g1, g2, g3 := journalGame("1", 1, "A", "B"), journalGame("2", 2, "B", "A"), journalGame("3", 3, "A", "C")
+	if _, err := l.Sync([]analyzer.Game{g1, g2}); err != nil {
+		t.Fatal(err)
+	}
+	// rows moving in the file is not a change
+	g1.Line = 7
+	if _, err := l.Sync([]analyzer.Game{g1, g2}); err != nil {
+		t.Fatal(err)
+	}
+	g2.Notes = "scorekeeper fixed a typo"
+	if _, err := l.Sync([]analyzer.Game{g2, g3}); err != nil {
+		t.Fatal(err)
+	}
+	events, _ := journal.Events(0)
+	var types []EventType
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	want := []EventType{Recorded, Recorded, Corrected, Recorded, Voided}
+	if !reflect.DeepEqual(types, want) {
+		t.Fatalf("journaled %v, want %v", types, want)
+	}
+
+	if _, err := l.Sync([]analyzer.Game{g2, g2}); err == nil {
+		t.Fatal("expected duplicate IDs to fail")
+	}
+}
+
+func TestLedgerReplayIsDeterministic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "guildmaster.db")
+	db, err := OpenBolt(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := NewLedger(db, db, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.SnapshotEvery = 3
+	games := []analyzer.Game{
+		journalGame("1", 1, "A", "B", "C"),
+		journalGame("2", 2, "B", "A"),
+		journalGame("3", 3, "C", "B", "A"),
+		journalGame("4", 4, "A", "C"),
+	}
+	for _, g := range games {
+		if _, _, err := l.Record(g); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := l.Void("2"); err != nil {
+		t.Fatal(err)
+	}
+	snap, ok, err := db.LatestSnapshot()
+	if err != nil || !ok || snap.Seq != 3 {
+		t.Fatalf("expected a snapshot after 3 events, got %+v %v %v", snap, ok, err)
+	}
+	scores := db.GetAll()
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// replay from the snapshot, and from the start into a fresh store
+	db, err = OpenBolt(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := NewLedger(db, db, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := db.GetAll(); !reflect.DeepEqual(got, scores) {
+		t.Fatalf("scores after replaying from the snapshot: got %v, want %v", got, scores)
+	}
+	events, err := db.Events(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	full := NewMemoryJournal()
+	for _, e := range events {
+		if _, err := full.Append(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store := NewStore()
+	if _, err := NewLedger(full, store, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := store.GetAll(); !reflect.DeepEqual(got, scores) {
+		t.Fatalf("scores after a full replay: got %v, want %v", got, scores)
+	}
+}
+
+func TestLedgerRatesSnapshotAgain(t *testing.T) {
+	journal := NewMemoryJournal()
+	l, err := NewLedger(journal, NewStore(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.SnapshotEvery = 1
+	if _, _, err := l.Record(journalGame("1", 1, "A", "B")); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := journal.LatestSnapshot(); !ok {
+		t.Fatal("expected a snapshot")
+	}
+
+	// a restart with other rating settings rates the snapshot's games with them
+	store := NewStore()
+	rate := func(games []analyzer.Game) (map[string]int, error) {
+		return map[string]int{"A": len(games)}, nil
+	}
+	if _, err := NewLedger(journal, store, rate); err != nil {
+		t.Fatal(err)
+	}
+	if got := store.GetAll(); !reflect.DeepEqual(got, map[string]int{"A": 1}) {
+		t.Fatalf("scores after restarting from the snapshot: %v", got)
+	}
+}
+
+func TestLedgerManualEditsSurviveSync(t *testing.T) {
+	l, err := NewLedger(NewMemoryJournal(), NewStore(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g1, g2 := journalGame("1", 1, "A", "B"), journalGame("2", 2, "B", "A")
+	source := []analyzer.Game{g1, g2}
+	if _, err := l.Sync(source); err != nil {
+		t.Fatal(err)
+	}
+
+	fixed := g1
+	fixed.Rankings = []string{"B", "A"}
+	changes, err := l.Correct(fixed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 || changes[0].Player != "A" || changes[0].Delta() >= 0 || changes[1].Delta() <= 0 {
+		t.Fatalf("unexpected rating changes %+v", changes)
+	}
+	if _, err := l.Void("2"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the source still has the original games, which must not undo the edits
+	if _, err := l.Sync(source); err != nil {
+		t.Fatal(err)
+	}
+	if got := l.Games(); len(got) != 1 || got[0].Rankings[0] != "B" {
+		t.Fatalf("edits were lost on sync: %+v", got)
+	}
+
+	// a later change in the source wins
+	g1.Notes = "fixed in the sheet"
+	if _, err := l.Sync([]analyzer.Game{g1, g2}); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := l.Game("1"); !ok || got.Notes != g1.Notes || got.Rankings[0] != "A" {
+		t.Fatalf("expected the source's correction, got %+v", got)
+	}
+
+	history, err := l.History("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 3 || history[0].Type != Recorded || !history[1].Manual || history[2].Manual {
+		t.Fatalf("unexpected history %+v", history)
+	}
+}
+
+func TestLedgerConfirmation(t *testing.T) {
+	store := NewStore()
+	journal := NewMemoryJournal()
+	l, err := NewLedger(journal, store, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g1 := journalGame("1", 1, "A", "B", "C")
+	if _, err := l.Sync([]analyzer.Game{g1}); err != nil {
+		t.Fatal(err)
+	}
+
+	pending := journalGame("2", 2, "C", "A/D", "B")
+	pending.Status = analyzer.Pending
+	if _, changes, err := l.Record(pending); err != nil || len(changes) != 0 {
+		t.Fatalf("recording a pending game changed ratings: %v, %v", changes, err)
+	}
+	if got := len(l.Games()); got != 1 {
+		t.Fatalf("pending game listed as scored: %d games", got)
+	}
+	if _, _, err := l.Confirm("2", "E", false); !errors.Is(err, ErrNotPlayer) {
+		t.Fatalf("expected a non-player's confirmation to fail, got %v", err)
+	}
+	// 4 players, so 3 must confirm; confirming twice counts once
+	for _, by := range []string{"C", "C", "D"} {
+		g, changes, err := l.Confirm("2", by, false)
+		if err != nil || g.Status != analyzer.Pending || len(changes) != 0 {
+			t.Fatalf("confirmation by %s: status %s, changes %v, err %v", by, g.Status, changes, err)
+		}
+	}
+	g, changes, err := l.Confirm("2", "B", false)
+	if err != nil || g.Status != analyzer.Confirmed || len(changes) == 0 {
+		t.Fatalf("expected the third confirmation to score the game: status %s, changes %v, err %v", g.Status, changes, err)
+	}
+	if want := []string{"C", "D", "B"}; !reflect.DeepEqual(g.ConfirmedBy, want) {
+		t.Errorf("confirmed by %v, want %v", g.ConfirmedBy, want)
+	}
+	if _, err := l.Reject("2", "A", false); !errors.Is(err, ErrNotPending) {
+		t.Fatalf("expected rejecting a confirmed game to fail, got %v", err)
+	}
+
+	disputed := journalGame("3", 3, "B", "C")
+	disputed.Status, disputed.ConfirmedBy = analyzer.Pending, []string{"B"}
+	if _, _, err := l.Record(disputed); err != nil {
+		t.Fatal(err)
+	}
+	if g, err := l.Reject("3", "C", false); err != nil || g.Status != analyzer.Rejected || g.RejectedBy != "C" {
+		t.Fatalf("reject: %+v, %v", g, err)
+	}
+	if _, _, err := l.Confirm("3", "B", false); !errors.Is(err, ErrNotPending) {
+		t.Fatalf("expected confirming a rejected game to fail, got %v", err)
+	}
+	if got := store.GetAll(); !reflect.DeepEqual(got, rated(t, g1, g)) {
+		t.Fatalf("scores count a rejected game: got %v, want %v", got, rated(t, g1, g))
+	}
+	g3, _, err := l.Confirm("3", "keeper", true)
+	if err != nil || g3.Status != analyzer.Confirmed || g3.ApprovedBy != "keeper" {
+		t.Fatalf("approve: %+v, %v", g3, err)
+	}
+	if got := store.GetAll(); !reflect.DeepEqual(got, rated(t, g1, g, g3)) {
+		t.Fatalf("scores after approval: got %v, want %v", got, rated(t, g1, g, g3))
+	}
+
+	// replaying the journal gives the same state
+	replayed := NewStore()
+	if _, err := NewLedger(journal, replayed, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(replayed.GetAll(), store.GetAll()) {
+		t.Fatalf("replayed scores %v, want %v", replayed.GetAll(), store.GetAll())
+	}
+}
+
+func TestLedgerQuorum(t *testing.T) {
+	l, err := NewLedger(NewMemoryJournal(), NewStore(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Quorum = 1
+	g := journalGame("1", 1, "A", "B", "C")
+	g.Status, g.ConfirmedBy = analyzer.Pending, []string{"A"}
+	if g, _, err := l.Record(g); err != nil || g.Status != analyzer.Confirmed {
+		t.Fatalf("expected the recording player to make a quorum of 1: %s, %v", g.Status, err)
+	}
+	l.Quorum = 4
+	g = journalGame("3", 3, "A", "B", "C")
+	g.Status = analyzer.Pending
+	if _, _, err := l.Record(g); err != nil {
+		t.Fatal(err)
+	}
+	if n := l.ConfirmationsNeeded(g); n != 3 {
+		t.Fatalf("a quorum of 4 needs %d confirmations of a 3-player game, want all 3", n)
+	}
+	for i, by := range []string{"A", "B", "C"} {
+		g, _, err := l.Confirm("3", by, false)
+		if want := i == 2; err != nil || (g.Status == analyzer.Confirmed) != want {
+			t.Fatalf("after %d confirmations: status %s, %v", i+1, g.Status, err)
+		}
+	}
+	l.Quorum = 0
+	g = journalGame("2", 2, "A", "B")
+	g.Status, g.ConfirmedBy = analyzer.Pending, []string{"A"}
+	if g, _, err := l.Record(g); err != nil || g.Status != analyzer.Pending {
+		t.Fatalf("expected both players of a 1v1 to be needed: %s, %v", g.Status, err)
+	}
+}
+
+func TestLedgerOnChange(t *testing.T) {
+	l, err := NewLedger(NewMemoryJournal(), NewStore(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got [][]Event
+	l.OnChange = func(events []Event, changes []RatingChange) {
+		if len(changes) == 0 {
+			t.Errorf("no rating changes with %v", events)
+		}
+		// the ledger must not be locked
+		if l.Seq() != events[len(events)-1].Seq {
+			t.Errorf("seq %d, want %d", l.Seq(), events[len(events)-1].Seq)
+		}
+		got = append(got, events)
+	}
+	games := []analyzer.Game{journalGame("1", 1, "A", "B"), journalGame("2", 2, "B", "C")}
+	if _, err := l.Sync(games); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Sync(games); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Void("1"); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || len(got[0]) != 2 || got[0][1].Seq != 2 || got[1][0].Type != Voided {
+		t.Fatalf("OnChange called with %+v", got)
+	}
+}
+
+func TestDiffScores(t *testing.T) {
+	got := DiffScores(map[string]int{"A": 1520, "B": 1480, "C": 1500}, map[string]int{"A": 1510, "C": 1500, "D": 1490})
+	want := []RatingChange{{"A", 1520, 1510}, {"B", 1480, 0}, {"D", 0, 1490}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestLedgerSyncRowsWithoutIDs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "games.csv")
+	rows := []string{
+		"id,date,player1,player2,player3",
+		",1/20/2020,Marshall,Colton,Dylan",
+		",1/20/2020,Marshall,Colton,Dylan", // the same result twice
+		",2/13/2020,Dylan,Marshall,Colton",
+		",2/20/2020,Colton,Dylan,Marshall",
+		",2/27/2020,Dylan,Colton,Marshall",
+	}
+	load := func(rows []string) []analyzer.Game {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(strings.Join(rows, "\n")+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		games, err := analyzer.CSVSource{Path: path}.Games(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		analyzer.SortChronologically(games)
+		return games
+	}
+
+	journal := NewMemoryJournal()
+	l, err := NewLedger(journal, NewStore(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Sync(load(rows)); err != nil {
+		t.Fatal(err)
+	}
+	// correct the 2/20 game by hand
+	games := l.Games()
+	i := slices.IndexFunc(games, func(g analyzer.Game) bool { return g.Date == "2/20/2020" })
+	id := games[i].ID
+	if _, err := strconv.Atoi(id); err == nil {
+		t.Fatalf("game journaled under its line number %s", id)
+	}
+	if _, _, err := l.Edit(id, func(g *analyzer.Game) error {
+		g.Rankings = []string{"Dylan", "Colton", "Marshall"}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	seq := l.Seq()
+
+	// deleting a row above it voids only that game
+	deleted := slices.Delete(slices.Clone(rows), 3, 4)
+	if _, err := l.Sync(load(deleted)); err != nil {
+		t.Fatal(err)
+	}
+	events, err := journal.Events(seq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Type != Voided {
+		t.Fatalf("deleting a row journaled %+v, want one void", events)
+	}
+	// inserting one records only that game
+	seq = l.Seq()
+	inserted := slices.Insert(slices.Clone(deleted), 1, ",1/13/2020,Colton,Marshall,Dylan")
+	if _, err := l.Sync(load(inserted)); err != nil {
+		t.Fatal(err)
+	}
+	if events, err = journal.Events(seq); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Type != Recorded || events[0].Game.Date != "1/13/2020" {
+		t.Fatalf("inserting a row journaled %+v, want one recorded game", events)
+	}
+
+	g, ok := l.Game(id)
+	if !ok || !slices.Equal(g.Rankings, []string{"Dylan", "Colton", "Marshall"}) {
+		t.Fatalf("manual correction lost after rows moved: %+v, %v", g, ok)
+	}
+	if n := len(l.Games()); n != 5 {
+		t.Fatalf("%d games after deleting and inserting a row, want 5", n)
+	}
+}
+
+func TestLedgerSyncWrittenGame(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "games.csv")
+	rows := "date,player1,player2,player3\n1/20/2020,Marshall,Colton,Dylan\n2/13/2020,Dylan,Marshall,Colton\n"
+	if err := os.WriteFile(path, []byte(rows), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	src := analyzer.CSVSource{Path: path}
+	sync := func(l *Ledger) []Event {
+		t.Helper()
+		games, err := src.Games(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		seq := l.Seq()
+		if _, err := l.Sync(games); err != nil {
+			t.Fatal(err)
+		}
+		events, err := l.journal.Events(seq)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return events
+	}
+
+	journal := NewMemoryJournal()
+	l, err := NewLedger(journal, NewStore(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sync(l)
+	g := journalGame("j3", 1, "Colton", "Dylan", "Marshall")
+	g.Date, g.Status, g.ConfirmedBy = "3/1/2020", analyzer.Pending, []string{"Colton", "Dylan"}
+	if g, _, err = l.Record(g); err != nil || g.Status != analyzer.Confirmed {
+		t.Fatalf("game not confirmed: %s, %v", g.Status, err)
+	}
+	// the server writes the confirmed game back to the game log, which has no
+	// ID column, so the row comes back under its content ID
+	if err := src.AppendGame(context.Background(), g); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range sync(l) {
+		if e.Type == Recorded {
+			t.Fatalf("the written game was recorded again: %+v", e)
+		}
+	}
+	if n := len(l.Games()); n != 3 {
+		t.Fatalf("%d games after syncing the written game, want 3", n)
+	}
+	if got, ok := l.Game("j3"); !ok || !slices.Equal(got.ConfirmedBy, g.ConfirmedBy) {
+		t.Fatalf("written game lost its confirmations: %+v, %v", got, ok)
+	}
+	if events := sync(l); len(events) != 0 {
+		t.Fatalf("syncing again journaled %+v, want nothing", events)
+	}
+
+	// the match doesn't depend on state lost on restart
+	l, err = NewLedger(journal, NewStore(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if events := sync(l); len(events) != 0 {
+		t.Fatalf("syncing after a restart journaled %+v, want nothing", events)
+	}
+
+	// the game log still has the game as written, so an edit made through the
+	// API is kept rather than the row being taken for a new game
+	edited, _, err := l.Edit("j3", func(g *analyzer.Game) error {
+		g.Rankings = []string{"Dylan", "Colton", "Marshall"}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		if events := sync(l); len(events) != 0 {
+			t.Fatalf("syncing after an edit journaled %+v, want nothing", events)
+		}
+		if got, ok := l.Game("j3"); !ok || !slices.Equal(got.Rankings, edited.Rankings) {
+			t.Fatalf("edit was reverted: %+v, %v", got, ok)
+		}
+		if n := len(l.Games()); n != 3 {
+			t.Fatalf("%d games after syncing the edited game, want 3", n)
+		}
+		if l, err = NewLedger(journal, NewStore(), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLedgerEdit(t *testing.T) {
+	l, err := NewLedger(NewMemoryJournal(), NewStore(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := l.Record(journalGame("1", 1, "A", "B", "C")); err != nil {
+		t.Fatal(err)
+	}
+	edited, changes, err := l.Edit("1", func(g *analyzer.Game) error {
+		g.Rankings = []string{"C", "B", "A"}
+		g.ID = "renamed" // the ID can't be edited
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if edited.ID != "1" || !reflect.DeepEqual(edited.Rankings, []string{"C", "B", "A"}) || len(changes) == 0 {
+		t.Fatalf("edit = %+v, %v", edited, changes)
+	}
+
+	seq := l.Seq()
+	invalid := errors.New("invalid")
+	if _, _, err := l.Edit("1", func(g *analyzer.Game) error { return invalid }); !errors.Is(err, invalid) {
+		t.Fatalf("expected the edit's error, got %v", err)
+	}
+	if _, _, err := l.Edit("1", func(g *analyzer.Game) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if l.Seq() != seq {
+		t.Fatalf("failed or empty edits were journaled: seq %d, want %d", l.Seq(), seq)
+	}
+	if _, _, err := l.Edit("2", func(g *analyzer.Game) error { return nil }); !errors.Is(err, ErrNoGame) {
+		t.Fatalf("expected editing a missing game to fail, got %v", err)
+	}
+
+	g := journalGame("3", 3, "A", "B", "C")
+	g.Status = analyzer.Pending
+	if _, _, err := l.Record(g); err != nil {
+		t.Fatal(err)
+	}
+	// a confirmation made after a client read the game must survive its edit
+	if _, _, err := l.Confirm("3", "B", false); err != nil {
+		t.Fatal(err)
+	}
+	if edited, _, err = l.Edit("3", func(g *analyzer.Game) error {
+		g.Notes = "close game"
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if edited.Notes != "close game" || !reflect.DeepEqual(edited.ConfirmedBy, []string{"B"}) {
+		t.Fatalf("edited game %+v lost its confirmation", edited)
+	}
+
+	// confirmations from players an edit removes no longer count
+	g = journalGame("4", 4, "A", "B", "C", "D", "E", "F")
+	g.Status, g.ConfirmedBy = analyzer.Pending, []string{"A", "B"}
+	if _, _, err := l.Record(g); err != nil {
+		t.Fatal(err)
+	}
+	if edited, _, err = l.Edit("4", func(g *analyzer.Game) error {
+		g.Rankings = []string{"C", "D", "E", "F"}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(edited.ConfirmedBy) != 0 {
+		t.Fatalf("edited game kept confirmations from removed players: %v", edited.ConfirmedBy)
+	}
+	if confirmed, _, err := l.Confirm("4", "C", false); err != nil || confirmed.Status != analyzer.Pending {
+		t.Fatalf("one confirmation of four players = %s, %v; want pending", confirmed.Status, err)
+	}
+	// nor do confirmations recorded from people who didn't play
+	g = journalGame("5", 5, "C", "D", "E", "F")
+	g.Status, g.ConfirmedBy = analyzer.Pending, []string{"A", "B"}
+	if _, _, err := l.Record(g); err != nil {
+		t.Fatal(err)
+	}
+	if confirmed, _, err := l.Confirm("5", "C", false); err != nil || confirmed.Status != analyzer.Pending {
+		t.Fatalf("one confirmation of four players = %s, %v; want pending", confirmed.Status, err)
+	}
+}
@@ -4,15 +4,43 @@ import (
 	"errors"
 	"maps"
 	"math"
+	"slices"
 	"sync"
+
+	"github.com/dylanlott/guildmaster/internal/analyzer"
 )
 
+// Storage keeps player ratings and the games they were computed from. Reads
+// never fail; implementations serve them from memory. Store keeps everything
+// in memory and BoltStore also persists it to disk. Scores are only ever
+// replaced wholesale, with the result of rating the games, so they can't
+// drift from them; a Ledger keeps a Storage in step with its journal.
+type Storage interface {
+	// GetAll returns a copy of all scores.
+	GetAll() map[string]int
+	// ReplaceAll replaces every score with the provided snapshot.
+	ReplaceAll(scores map[string]int) error
+
+	// Games returns a copy of the stored games in the order they were added.
+	Games() []analyzer.Game
+	// AddGame stores a game after the existing ones.
+	AddGame(g analyzer.Game) error
+	// ReplaceGames replaces every stored game.
+	ReplaceGames(games []analyzer.Game) error
+
+	// Close releases the storage.
+	Close() error
+}
+
 // Simple in-memory scoring store for player Elo ratings.
 type Store struct {
 	mu     sync.RWMutex
 	scores map[string]int
+	games  []analyzer.Game
 }
 
+var _ Storage = (*Store)(nil)
+
 // NewStore creates a new in-memory store.
 func NewStore() *Store {
 	return &Store{scores: make(map[string]int)}
@@ -28,22 +56,24 @@ func (s *Store) GetAll() map[string]int {
 }
 
 // Set sets a player's score.
-func (s *Store) Set(player string, score int) {
+func (s *Store) Set(player string, score int) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.scores[player] = score
+	return nil
 }
 
 // ReplaceAll atomically replaces the entire scores map with the provided snapshot.
-func (s *Store) ReplaceAll(newScores map[string]int) {
+func (s *Store) ReplaceAll(newScores map[string]int) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.scores = make(map[string]int, len(newScores))
 	maps.Copy(s.scores, newScores)
+	return nil
 }
 
 // ApplyDeltas applies integer deltas to players (adds delta to existing or default 1500).
-func (s *Store) ApplyDeltas(deltas map[string]int) {
+func (s *Store) ApplyDeltas(deltas map[string]int) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	const defaultStartingScore = 1500
@@ -54,8 +84,35 @@ func (s *Store) ApplyDeltas(deltas map[string]int) {
 		}
 		s.scores[player] = cur + delta
 	}
+	return nil
+}
+
+// Games returns a copy of the stored games.
+func (s *Store) Games() []analyzer.Game {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.games)
 }
 
+// AddGame appends a game.
+func (s *Store) AddGame(g analyzer.Game) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.games = append(s.games, g)
+	return nil
+}
+
+// ReplaceGames replaces every stored game.
+func (s *Store) ReplaceGames(games []analyzer.Game) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.games = slices.Clone(games)
+	return nil
+}
+
+// Close is a no-op for the in-memory store.
+func (s *Store) Close() error { return nil }
+
 // ScoreGame computes Elo deltas for a finished game (players ordered by finish: winner first).
 // It returns the computed deltas but does not persist them; caller can persist via ApplyDeltas.
 func ScoreGame(game []string, K int, D float64, snapshot map[string]int) (map[string]int, error) {
@@ -0,0 +1,157 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dylanlott/guildmaster/internal/analyzer"
+	"github.com/dylanlott/guildmaster/internal/scoring"
+)
+
+// heartbeat is how often an idle /api/events stream sends a comment, so
+// proxies don't close it.
+const heartbeat = 30 * time.Second
+
+// broker fans messages out to the clients of /api/events. The zero value is
+// ready to use.
+type broker struct {
+	mu   sync.Mutex
+	subs map[chan []byte]struct{}
+}
+
+// subscribe returns a channel receiving every message published from now on.
+// It is closed if the client falls too far behind.
+func (b *broker) subscribe() chan []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subs == nil {
+		b.subs = make(map[chan []byte]struct{})
+	}
+	ch := make(chan []byte, 16)
+	b.subs[ch] = struct{}{}
+	return ch
+}
+
+func (b *broker) unsubscribe(ch chan []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subs[ch]; ok {
+		delete(b.subs, ch)
+		close(ch)
+	}
+}
+
+// publish sends msg to every subscriber without waiting. A client too slow to
+// take it is dropped; its browser reconnects and catches up.
+func (b *broker) publish(msg []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subs {
+		select {
+		case ch <- msg:
+		default:
+			delete(b.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// scoreUpdate is the data of an "update" event on /api/events: what changed,
+// and the state the landing page shows so it can redraw from any one update.
+type scoreUpdate struct {
+	Seq     uint64                 `json:"seq"`
+	Events  []scoring.Event        `json:"events"`
+	Changes []scoring.RatingChange `json:"changes"`
+	Scores  map[string]int         `json:"scores"`
+	Recent  []analyzer.Game        `json:"recent"` // latest 10 scored games, newest first
+	Pending []PendingGame          `json:"pending"`
+	At      time.Time              `json:"at"`
+}
+
+// publishChange tells the clients of /api/events about a change to the games.
+func (s *Server) publishChange(events []scoring.Event, changes []scoring.RatingChange) {
+	msg, err := s.updateEvent(events, changes)
+	if err != nil {
+		log.Printf("league %s: failed to publish update: %v", s.ID, err)
+		return
+	}
+	s.events.publish(msg)
+}
+
+// updateEvent formats the current state as an "update" Server-Sent Event.
+func (s *Server) updateEvent(events []scoring.Event, changes []scoring.RatingChange) ([]byte, error) {
+	u := scoreUpdate{Events: events, Changes: changes, Scores: s.store.GetAll(), Pending: s.pendingGames(), At: time.Now().UTC()}
+	if u.Events == nil {
+		u.Events = []scoring.Event{}
+	}
+	if u.Changes == nil {
+		u.Changes = []scoring.RatingChange{}
+	}
+	if s.Ledger != nil {
+		u.Seq = s.Ledger.Seq()
+	}
+	u.Recent = recentGames(s.currentGames())
+	data, err := json.Marshal(u)
+	if err != nil {
+		return nil, err
+	}
+	return fmt.Appendf(nil, "id: %d\nevent: update\ndata: %s\n\n", u.Seq, data), nil
+}
+
+// GET /api/events - streams Server-Sent Events: an "update" whenever a
+// refresh or an edit through the API changes the games or ratings, carrying
+// the journal events, the rating changes and the current scoreboard (see
+// scoreUpdate). A client reconnecting with a Last-Event-ID it missed updates
+// since gets the current state straight away.
+func (s *Server) HandleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	ch := s.events.subscribe()
+	defer s.events.unsubscribe(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // keep nginx from buffering the stream
+	fmt.Fprint(w, "retry: 5000\n\n")
+	if last := r.Header.Get("Last-Event-ID"); last != "" && s.Ledger != nil && last != strconv.FormatUint(s.Ledger.Seq(), 10) {
+		msg, err := s.updateEvent(nil, nil)
+		if err != nil {
+			return
+		}
+		_, _ = w.Write(msg)
+	}
+	flusher.Flush()
+
+	ping := time.NewTicker(heartbeat)
+	defer ping.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			if _, err := w.Write(msg); err != nil {
+				return
+			}
+		case <-ping.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
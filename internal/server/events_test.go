@@ -0,0 +1,129 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dylanlott/guildmaster/internal/analyzer"
+)
+
+// eventStream reads Server-Sent Events from GET /api/events.
+type eventStream struct {
+	r *bufio.Reader
+}
+
+func openEvents(t *testing.T, url, lastID string) eventStream {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+"/api/events", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, want text/event-stream", ct)
+	}
+	return eventStream{bufio.NewReader(resp.Body)}
+}
+
+// next returns the fields of the next event, skipping comments.
+func (s eventStream) next(t *testing.T) map[string]string {
+	t.Helper()
+	fields := make(map[string]string)
+	for {
+		line, err := s.r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading events: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && len(fields) > 0:
+			return fields
+		case line == "" || strings.HasPrefix(line, ":"):
+		default:
+			name, value, _ := strings.Cut(line, ": ")
+			fields[name] = value
+		}
+	}
+}
+
+// update returns the next event, which must be an update.
+func (s eventStream) update(t *testing.T) scoreUpdate {
+	t.Helper()
+	e := s.next(t)
+	if e["event"] != "update" {
+		t.Fatalf("got event %v, want an update", e)
+	}
+	var u scoreUpdate
+	if err := json.Unmarshal([]byte(e["data"]), &u); err != nil {
+		t.Fatal(err)
+	}
+	if id, err := strconv.ParseUint(e["id"], 10, 64); err != nil || id != u.Seq {
+		t.Errorf("update ID %q, want its sequence number %d", e["id"], u.Seq)
+	}
+	return u
+}
+
+func TestEvents(t *testing.T) {
+	s, h := newTestServer(t, testGame("1", 1, "A", "B", "C"))
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close) // after the streams are closed
+
+	stream := openEvents(t, srv.URL, "")
+	if e := stream.next(t); e["retry"] == "" {
+		t.Fatalf("first event %v, want the retry delay", e)
+	}
+
+	g := testGame("x1", 2, "C", "B")
+	g.Status = analyzer.Pending
+	if _, _, err := s.Ledger.Record(g); err != nil {
+		t.Fatal(err)
+	}
+	u := stream.update(t)
+	if len(u.Events) != 1 || u.Events[0].Game.ID != "x1" || u.Seq != s.Ledger.Seq() {
+		t.Fatalf("update = %+v, want the recorded game", u)
+	}
+	if len(u.Pending) != 1 || u.Pending[0].ID != "x1" || u.Pending[0].Needed != 2 {
+		t.Errorf("pending games = %+v, want x1 needing 2 confirmations", u.Pending)
+	}
+	if len(u.Changes) != 0 || len(u.Recent) != 1 {
+		t.Errorf("a pending game changed the scoreboard: %+v", u)
+	}
+
+	if _, _, err := s.Ledger.Confirm("x1", "", true); err != nil {
+		t.Fatal(err)
+	}
+	u = stream.update(t)
+	if len(u.Changes) == 0 || len(u.Pending) != 0 || len(u.Recent) != 2 || u.Recent[0].ID != "x1" {
+		t.Errorf("update after confirming = %+v, want x1 scored", u)
+	}
+	if u.Scores["C"] != s.store.GetAll()["C"] {
+		t.Errorf("update scores %v, want the stored ones", u.Scores)
+	}
+
+	// a client that missed updates gets the current state straight away
+	stream = openEvents(t, srv.URL, "1")
+	stream.next(t)
+	if u := stream.update(t); u.Seq != s.Ledger.Seq() || len(u.Events) != 0 || len(u.Recent) != 2 {
+		t.Errorf("catch-up update = %+v", u)
+	}
+
+	if rec := request(h, http.MethodPost, "/api/events", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST /api/events: status %d, want 405", rec.Code)
+	}
+}
@@ -0,0 +1,519 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dylanlott/guildmaster/internal/analyzer"
+	"github.com/dylanlott/guildmaster/internal/auth"
+	"github.com/dylanlott/guildmaster/internal/scoring"
+)
+
+// gameInput is the body of POST /api/game. Finishers are given either as
+// players, winner first, or as placements, which allow teams and decks.
+type gameInput struct {
+	// ID is optional; the journal assigns one when it is empty.
+	ID         string           `json:"id"`
+	Players    []string         `json:"players"`
+	Placements []placementInput `json:"placements"`
+	// Date defaults to today and is parsed with the server's date layouts
+	// unless Timestamp is also given.
+	Date         string                 `json:"date"`
+	Timestamp    *time.Time             `json:"timestamp"`
+	TableZap     bool                   `json:"table_zap"`
+	Draw         bool                   `json:"draw"`
+	Turns        int                    `json:"turns"`
+	TurnOrder    []string               `json:"turn_order"`
+	Eliminations []analyzer.Elimination `json:"eliminations"`
+	Notes        string                 `json:"notes"`
+}
+
+// placementInput is one finishing position: a player or a team.
+type placementInput struct {
+	Players []string `json:"players"`
+	Deck    string   `json:"deck"`
+}
+
+// game validates the input and converts it to a game.
+func (in gameInput) game(dates analyzer.DateParser) (analyzer.Game, error) {
+	g := analyzer.Game{
+		ID:           in.ID,
+		Date:         in.Date,
+		Turns:        in.Turns,
+		TurnOrder:    in.TurnOrder,
+		Eliminations: in.Eliminations,
+		Notes:        in.Notes,
+	}
+	if in.TableZap {
+		g.TableZap = "TRUE"
+	}
+	if in.Draw {
+		g.DrawGame = "TRUE"
+	}
+	switch {
+	case len(in.Players) > 0 && len(in.Placements) > 0:
+		return g, fmt.Errorf("give players or placements, not both")
+	case len(in.Players) > 0:
+		g.Rankings = in.Players
+	default:
+		for _, p := range in.Placements {
+			name := strings.Join(p.Players, analyzer.TeamSeparator)
+			g.Rankings = append(g.Rankings, name)
+			if p.Deck != "" {
+				if g.Decks == nil {
+					g.Decks = make(map[string]string)
+				}
+				g.Decks[name] = p.Deck
+			}
+		}
+	}
+	if err := checkRankings(g.Rankings); err != nil {
+		return g, err
+	}
+	if in.Turns < 0 {
+		return g, fmt.Errorf("turns can't be negative")
+	}
+
+	switch {
+	case in.Timestamp != nil:
+		g.Timestamp = *in.Timestamp
+		if g.Date == "" {
+			g.Date = in.Timestamp.Format(time.DateOnly)
+		}
+	default:
+		if g.Date == "" {
+			loc := dates.Location
+			if loc == nil {
+				loc = time.UTC
+			}
+			g.Date = time.Now().In(loc).Format("1/2/2006")
+		}
+		ts, err := dates.Parse(g.Date)
+		if err != nil {
+			return g, err
+		}
+		g.Timestamp = ts
+	}
+	return g, nil
+}
+
+// checkRankings reports whether a finishing order can be rated.
+func checkRankings(rankings []string) error {
+	if len(rankings) < 2 {
+		return fmt.Errorf("need at least 2 players, got %d", len(rankings))
+	}
+	for i, name := range rankings {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("player %d has no name", i+1)
+		}
+		if slices.Index(rankings, name) != i {
+			return fmt.Errorf("%s is listed more than once", name)
+		}
+	}
+	return nil
+}
+
+// POST /api/game - records a game (see gameInput), pending until its players
+// confirm it (see HandleConfirm); a token holder who played in it confirms it
+// by recording it. Responds 201 with the game and, once it is scored, each
+// of its players' rating change and every rating that changed, e.g. for a
+// backdated game.
+func (s *Server) HandleCreateGame(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.Ledger == nil {
+		http.Error(w, "games can't be recorded without a journal", http.StatusNotImplemented)
+		return
+	}
+	var in gameInput
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&in); err != nil {
+		http.Error(w, "invalid game: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	g, err := in.game(s.Dates)
+	if err != nil {
+		http.Error(w, "invalid game: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	g.Status = analyzer.Pending
+	if tok, ok := auth.FromContext(r.Context()); ok && slices.Contains(g.Players(), tok.Name) {
+		g.ConfirmedBy = []string{tok.Name}
+	}
+	g, changes, err := s.Ledger.Record(g)
+	if errors.Is(err, scoring.ErrGameExists) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	resp := struct {
+		gameChange
+		Deltas map[string]int `json:"deltas"`
+	}{gameChange{Game: &g, Changes: changes}, make(map[string]int)}
+	if resp.Changes == nil {
+		resp.Changes = []scoring.RatingChange{}
+	}
+	for _, c := range changes {
+		if slices.Contains(g.Rankings, c.Player) {
+			resp.Deltas[c.Player] = c.Delta()
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/games/"+url.PathEscape(g.ID))
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// writeTimeout bounds writing a confirmed game back to the game source.
+const writeTimeout = 30 * time.Second
+
+// onChange is the ledger's OnChange hook: it writes the games confirmed
+// through the API back to the game source and tells the clients of /api/events.
+func (s *Server) onChange(events []scoring.Event, changes []scoring.RatingChange) {
+	for _, e := range events {
+		// a game is journaled as confirmed once, by the event that confirms it
+		if e.Manual && (e.Type == scoring.Recorded || e.Type == scoring.Confirmed) && e.Game.Status == analyzer.Confirmed {
+			s.writeGame(e.Game)
+		}
+	}
+	s.publishChange(events, changes)
+}
+
+// writeGame appends a confirmed game to the game source when it can be
+// written to, so the game log stays complete. The next sync matches
+// the row back to the journaled game rather than recording it again (see
+// scoring.Ledger.Sync). A failed write is only logged: the game is scored
+// either way.
+func (s *Server) writeGame(g analyzer.Game) {
+	w, ok := s.source.(analyzer.GameWriter)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+	if err := w.AppendGame(ctx, g); err != nil {
+		log.Printf("league %s: game %s is scored but couldn't be written to the game source: %v", s.ID, g.ID, err)
+	}
+}
+
+// filterGames returns the games matching the query parameters of GET /api/games:
+//
+//	player - games with this player, alone or in a team; repeat it for games with all of them
+//	since, until - games on or after, and on or before, these dates
+//	limit - only the most recent games, up to this many
+//	status - pending, confirmed or rejected games, or all of them; scored games by default
+func filterGames(games []analyzer.Game, q url.Values, dates analyzer.DateParser) ([]analyzer.Game, error) {
+	var since, until time.Time
+	var err error
+	status := analyzer.GameStatus(q.Get("status"))
+	switch status {
+	case "", "all", analyzer.Pending, analyzer.Confirmed, analyzer.Rejected:
+	default:
+		return nil, fmt.Errorf("status: want pending, confirmed, rejected or all, got %q", status)
+	}
+	if v := q.Get("since"); v != "" {
+		if since, err = dates.Parse(v); err != nil {
+			return nil, fmt.Errorf("since: %w", err)
+		}
+	}
+	if v := q.Get("until"); v != "" {
+		if until, err = dates.Parse(v); err != nil {
+			return nil, fmt.Errorf("until: %w", err)
+		}
+		// a date without a time covers the whole day
+		if h, m, sec := until.Clock(); h == 0 && m == 0 && sec == 0 && until.Nanosecond() == 0 {
+			until = until.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		}
+	}
+	limit := 0
+	if v := q.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 {
+			return nil, fmt.Errorf("limit: want a positive number, got %q", v)
+		}
+	}
+	players := q["player"]
+
+	out := []analyzer.Game{}
+	for _, g := range games {
+		if !since.IsZero() && g.Timestamp.Before(since) || !until.IsZero() && g.Timestamp.After(until) {
+			continue
+		}
+		switch status {
+		case "all":
+		case "", analyzer.Confirmed:
+			if !g.Scored() {
+				continue
+			}
+		default:
+			if g.Status != status {
+				continue
+			}
+		}
+		members := g.Players()
+		if !slices.ContainsFunc(players, func(p string) bool { return !slices.Contains(members, p) }) {
+			out = append(out, g)
+		}
+	}
+	if limit > 0 && len(out) > limit {
+		out = out[len(out)-limit:]
+	}
+	return out, nil
+}
+
+// gameEdit is the body of PUT /api/games/{id}. Fields that are left out keep
+// their current value.
+type gameEdit struct {
+	// Rankings is the corrected finishing order, winner first.
+	Rankings []string `json:"rankings"`
+	// Date is parsed with the server's date layouts unless Timestamp is also given.
+	Date      *string    `json:"date"`
+	Timestamp *time.Time `json:"timestamp"`
+	Notes     *string    `json:"notes"`
+}
+
+// apply returns g with the edit made.
+func (e gameEdit) apply(g analyzer.Game, dates analyzer.DateParser) (analyzer.Game, error) {
+	if e.Rankings != nil {
+		if err := checkRankings(e.Rankings); err != nil {
+			return g, err
+		}
+		g.Rankings = e.Rankings
+	}
+	switch {
+	case e.Timestamp != nil:
+		g.Timestamp = *e.Timestamp
+		g.Date = e.Timestamp.Format(time.DateOnly)
+		if e.Date != nil {
+			g.Date = *e.Date
+		}
+	case e.Date != nil:
+		ts, err := dates.Parse(*e.Date)
+		if err != nil {
+			return g, err
+		}
+		g.Date, g.Timestamp = *e.Date, ts
+	}
+	if e.Notes != nil {
+		g.Notes = *e.Notes
+	}
+	return g, nil
+}
+
+// gameChange is the response to an edit: the game as it now stands (omitted
+// when voided) and how every affected rating changed.
+type gameChange struct {
+	Game    *analyzer.Game         `json:"game,omitempty"`
+	Changes []scoring.RatingChange `json:"changes"`
+}
+
+// /api/games/{id}
+//
+//	GET    - returns the game
+//	PUT    - corrects its finishing order, date or notes (see gameEdit) and re-rates every game after it
+//	DELETE - voids it and re-rates every game after it
+//
+// Edits are journaled, so the original entry is kept; see HandleGameHistory.
+func (s *Server) HandleGame(w http.ResponseWriter, r *http.Request) {
+	if s.Ledger == nil {
+		http.Error(w, "games can't be edited without a journal", http.StatusNotImplemented)
+		return
+	}
+	id := r.PathValue("id")
+	var (
+		changes []scoring.RatingChange
+		err     error
+	)
+	switch r.Method {
+	case http.MethodGet:
+		g, ok := s.Ledger.Game(id)
+		if !ok {
+			http.Error(w, fmt.Sprintf("game %s: %v", id, scoring.ErrNoGame), http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(g)
+		return
+	case http.MethodPut:
+		var edit gameEdit
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&edit); err != nil {
+			http.Error(w, "invalid game: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		// edit the game under the ledger's lock, so nothing landing meanwhile is lost
+		var invalid error
+		_, changes, err = s.Ledger.Edit(id, func(g *analyzer.Game) error {
+			edited, err := edit.apply(*g, s.Dates)
+			if err != nil {
+				invalid = err
+				return err
+			}
+			*g = edited
+			return nil
+		})
+		if invalid != nil {
+			http.Error(w, "invalid game: "+invalid.Error(), http.StatusBadRequest)
+			return
+		}
+	case http.MethodDelete:
+		changes, err = s.Ledger.Void(id)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if errors.Is(err, scoring.ErrNoGame) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	resp := gameChange{Changes: changes}
+	if resp.Changes == nil {
+		resp.Changes = []scoring.RatingChange{}
+	}
+	if g, ok := s.Ledger.Game(id); ok {
+		resp.Game = &g
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// GET /api/games/{id}/history - returns every journaled event for the game, oldest first
+func (s *Server) HandleGameHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.Ledger == nil {
+		http.Error(w, "game history needs a journal", http.StatusNotImplemented)
+		return
+	}
+	id := r.PathValue("id")
+	events, err := s.Ledger.History(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(events) == 0 {
+		http.Error(w, fmt.Sprintf("game %s: %v", id, scoring.ErrNoGame), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(events)
+}
+
+// confirmation is the body of POST /api/games/{id}/confirm and /reject. It is
+// only read when the server doesn't check tokens; otherwise the token says
+// who is confirming.
+type confirmation struct {
+	// Player names the player confirming or rejecting the game.
+	Player string `json:"player"`
+	// Approve confirms or rejects the game as a scorekeeper, for its players.
+	Approve bool `json:"approve"`
+}
+
+// confirmer returns who is confirming or rejecting a game and whether they
+// act as a scorekeeper. With a token, its holder confirms as a player when
+// they played in g and as a scorekeeper otherwise, if their role allows,
+// or when asked to approve.
+func confirmer(r *http.Request, g analyzer.Game) (by string, scorekeeper bool, status int, err error) {
+	var c confirmation
+	if r.ContentLength != 0 {
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&c); err != nil {
+			return "", false, http.StatusBadRequest, fmt.Errorf("invalid confirmation: %w", err)
+		}
+	}
+	tok, ok := auth.FromContext(r.Context())
+	if !ok {
+		if c.Player == "" && !c.Approve {
+			return "", false, http.StatusBadRequest, errors.New(`name the player confirming, e.g. {"player": "Dylan"}, or approve as a scorekeeper with {"approve": true}`)
+		}
+		return c.Player, c.Approve, 0, nil
+	}
+	keeper := tok.Role.Allows(auth.Scorekeeper)
+	if c.Approve && !keeper {
+		return "", false, http.StatusForbidden, fmt.Errorf("%s token can't approve games: need %s", tok.Role, auth.Scorekeeper)
+	}
+	return tok.Name, c.Approve || keeper && !slices.Contains(g.Players(), tok.Name), 0, nil
+}
+
+// POST /api/games/{id}/confirm - confirms a pending game. It is scored once a
+// quorum of its players have confirmed it (see Server.Quorum) or a
+// scorekeeper approves it. Responds with the game and every rating that changed.
+func (s *Server) HandleConfirm(w http.ResponseWriter, r *http.Request) {
+	s.handleConfirmation(w, r, true)
+}
+
+// POST /api/games/{id}/reject - disputes a pending game, so it isn't scored
+// unless a scorekeeper approves it later. Responds with the game.
+func (s *Server) HandleReject(w http.ResponseWriter, r *http.Request) {
+	s.handleConfirmation(w, r, false)
+}
+
+func (s *Server) handleConfirmation(w http.ResponseWriter, r *http.Request, confirm bool) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.Ledger == nil {
+		http.Error(w, "games can't be confirmed without a journal", http.StatusNotImplemented)
+		return
+	}
+	id := r.PathValue("id")
+	g, ok := s.Ledger.Game(id)
+	if !ok {
+		http.Error(w, fmt.Sprintf("game %s: %v", id, scoring.ErrNoGame), http.StatusNotFound)
+		return
+	}
+	by, keeper, status, err := confirmer(r, g)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	var changes []scoring.RatingChange
+	if confirm {
+		g, changes, err = s.Ledger.Confirm(id, by, keeper)
+	} else {
+		g, err = s.Ledger.Reject(id, by, keeper)
+	}
+	switch {
+	case errors.Is(err, scoring.ErrNoGame):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, scoring.ErrNotPlayer):
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	case errors.Is(err, scoring.ErrNotPending):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	resp := gameChange{Game: &g, Changes: changes}
+	if resp.Changes == nil {
+		resp.Changes = []scoring.RatingChange{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
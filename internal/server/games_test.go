@@ -0,0 +1,371 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dylanlott/guildmaster/internal/analyzer"
+	"github.com/dylanlott/guildmaster/internal/scoring"
+)
+
+// testSource is a game source serving games, or failing with err.
+type testSource struct {
+	mu    sync.Mutex
+	games []analyzer.Game
+	err   error
+}
+
+func (s *testSource) Games(context.Context) ([]analyzer.Game, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.games), s.err
+}
+
+func (s *testSource) fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+func testGame(id string, day int, rankings ...string) analyzer.Game {
+	ts := time.Date(2024, 3, day, 0, 0, 0, 0, time.UTC)
+	return analyzer.Game{ID: id, Date: ts.Format("1/2/2006"), Timestamp: ts, Rankings: rankings}
+}
+
+// newTestServer returns a league with a journal, refreshed from a source
+// serving games, and a handler serving its routes.
+func newTestServer(t *testing.T, games ...analyzer.Game) (*Server, http.Handler) {
+	t.Helper()
+	s := New(scoring.NewStore(), &testSource{games: games})
+	s.ID = "test"
+	if err := s.UseJournal(scoring.NewMemoryJournal()); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RefreshAndPersistScores(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ls, err := NewLeagues(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	ls.Register(mux)
+	return s, mux
+}
+
+// request serves a request with body through h.
+func request(h http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	var req *http.Request
+	if body == "" {
+		req = httptest.NewRequest(method, path, nil)
+	} else {
+		req = httptest.NewRequest(method, path, strings.NewReader(body))
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+// decodeChange decodes the gameChange in a response.
+func decodeChange(t *testing.T, rec *httptest.ResponseRecorder) gameChange {
+	t.Helper()
+	var c gameChange
+	if err := json.NewDecoder(rec.Body).Decode(&c); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// writableSource is a testSource that games can be written back to, failing
+// with writeErr.
+type writableSource struct {
+	testSource
+	writeErr error
+}
+
+func (s *writableSource) AppendGame(_ context.Context, g analyzer.Game) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.writeErr != nil {
+		return s.writeErr
+	}
+	// the game log keeps no ID, so the row comes back under its content ID
+	g.ID, g.LineID = "", true
+	s.games = append(s.games, g)
+	return nil
+}
+
+func TestCreateGame(t *testing.T) {
+	s, h := newTestServer(t, testGame("1", 1, "A", "B", "C"))
+	for _, tc := range []struct {
+		name, body string
+		status     int
+	}{
+		{"players and placements", `{"players": ["A", "B"], "placements": [{"players": ["A"]}, {"players": ["B"]}]}`, http.StatusBadRequest},
+		{"one player", `{"players": ["A"]}`, http.StatusBadRequest},
+		{"duplicate player", `{"players": ["A", "B", "A"]}`, http.StatusBadRequest},
+		{"blank player", `{"players": ["A", " "]}`, http.StatusBadRequest},
+		{"unknown field", `{"players": ["A", "B"], "winner": "A"}`, http.StatusBadRequest},
+		{"negative turns", `{"players": ["A", "B"], "turns": -1}`, http.StatusBadRequest},
+		{"bad date", `{"players": ["A", "B"], "date": "last tuesday"}`, http.StatusBadRequest},
+		{"not JSON", `A beat B`, http.StatusBadRequest},
+		{"ID from the game log", `{"id": "1", "players": ["A", "B"]}`, http.StatusConflict},
+		{"new game", `{"id": "x1", "players": ["B", "A"], "date": "3/2/2024"}`, http.StatusCreated},
+		{"same ID again", `{"id": "x1", "players": ["A", "B"], "date": "3/2/2024"}`, http.StatusConflict},
+	} {
+		rec := request(h, http.MethodPost, "/api/game", tc.body)
+		if rec.Code != tc.status {
+			t.Errorf("%s: status %d, want %d: %s", tc.name, rec.Code, tc.status, rec.Body)
+		}
+	}
+
+	g, ok := s.Ledger.Game("x1")
+	if !ok || g.Status != analyzer.Pending || !slices.Equal(g.Rankings, []string{"B", "A"}) || g.Date != "3/2/2024" {
+		t.Fatalf("recorded game = %+v, %v; want it pending", g, ok)
+	}
+	if scores := s.store.GetAll(); len(scores) != 3 {
+		t.Errorf("a pending game changed the ratings: %v", scores)
+	}
+
+	rec := request(h, http.MethodPost, "/api/game", `{"placements": [{"players": ["A", "B"], "deck": "Atraxa"}, {"players": ["C", "D"]}]}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("team game: status %d: %s", rec.Code, rec.Body)
+	}
+	c := decodeChange(t, rec)
+	if c.Game == nil || !slices.Equal(c.Game.Rankings, []string{"A/B", "C/D"}) || c.Game.Decks["A/B"] != "Atraxa" {
+		t.Fatalf("team game = %+v", c.Game)
+	}
+	if loc := rec.Header().Get("Location"); loc != "/api/games/"+c.Game.ID {
+		t.Errorf("Location = %q, want the game's URL", loc)
+	}
+
+	rec = request(h, http.MethodPost, "/api/game", `{"players": ["C", "A"]}`)
+	var resp struct {
+		Deltas map[string]int `json:"deltas"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Deltas) != 0 {
+		t.Errorf("deltas = %v, want none until the game is confirmed", resp.Deltas)
+	}
+
+	if rec := request(h, http.MethodGet, "/api/game", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /api/game: status %d, want 405", rec.Code)
+	}
+}
+
+func TestCreateGameWritesBack(t *testing.T) {
+	src := &writableSource{testSource: testSource{games: []analyzer.Game{testGame("1", 1, "A", "B", "C")}}}
+	s := New(scoring.NewStore(), src)
+	if err := s.UseJournal(scoring.NewMemoryJournal()); err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/game", s.HandleCreateGame)
+	mux.HandleFunc("/api/games/{id}/confirm", s.HandleConfirm)
+
+	if rec := request(mux, http.MethodPost, "/api/game", `{"id": "x1", "players": ["C", "B", "A"], "date": "3/2/2024"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	if n := len(src.games); n != 1 {
+		t.Fatalf("%d games in the game log, want the pending game left out", n)
+	}
+	for _, player := range []string{"C", "B"} {
+		if rec := request(mux, http.MethodPost, "/api/games/x1/confirm", `{"player": "`+player+`"}`); rec.Code != http.StatusOK {
+			t.Fatalf("confirm: status %d: %s", rec.Code, rec.Body)
+		}
+	}
+	if n := len(src.games); n != 2 {
+		t.Fatalf("%d games in the game log, want the confirmed game written back once", n)
+	}
+	if err := s.RefreshAndPersistScores(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if games := s.Ledger.Games(); len(games) != 2 || games[1].ID != "x1" || len(games[1].ConfirmedBy) != 2 {
+		t.Fatalf("games after a refresh = %+v, want the written game kept as x1 with its confirmations", games)
+	}
+
+	src.writeErr = errors.New("read-only")
+	if rec := request(mux, http.MethodPost, "/api/game", `{"id": "x2", "players": ["A", "B"]}`); rec.Code != http.StatusCreated {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	if rec := request(mux, http.MethodPost, "/api/games/x2/confirm", `{"approve": true}`); rec.Code != http.StatusOK {
+		t.Fatalf("failed write: status %d, want the game confirmed anyway: %s", rec.Code, rec.Body)
+	}
+	if g, _ := s.Ledger.Game("x2"); !g.Scored() {
+		t.Errorf("a failed write left the game unscored: %+v", g)
+	}
+}
+
+func TestFilterGames(t *testing.T) {
+	games := []analyzer.Game{
+		testGame("1", 1, "A", "B"),
+		testGame("2", 2, "B/C", "A/D"),
+		testGame("3", 3, "C", "A", "B"),
+	}
+	for query, want := range map[string][]string{
+		"":                                {"1", "2", "3"},
+		"player=C":                        {"2", "3"},
+		"player=A&player=C":               {"2", "3"},
+		"player=B&player=D":               {"2"},
+		"player=E":                        {},
+		"since=3/2/2024":                  {"2", "3"},
+		"until=3/2/2024":                  {"1", "2"},
+		"since=2024-03-02&until=3/2/2024": {"2"},
+		"limit=2":                         {"2", "3"},
+		"limit=2&player=B":                {"2", "3"},
+	} {
+		q, err := url.ParseQuery(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := filterGames(games, q, analyzer.DateParser{})
+		if err != nil {
+			t.Errorf("%q: %v", query, err)
+			continue
+		}
+		var ids []string
+		for _, g := range got {
+			ids = append(ids, g.ID)
+		}
+		if !slices.Equal(ids, want) {
+			t.Errorf("%q = %v, want %v", query, ids, want)
+		}
+	}
+	for _, query := range []string{"limit=0", "limit=ten", "since=someday", "until=2024-13-01"} {
+		q, _ := url.ParseQuery(query)
+		if _, err := filterGames(games, q, analyzer.DateParser{}); err == nil {
+			t.Errorf("%q: expected an error", query)
+		}
+	}
+
+	_, h := newTestServer(t, games...)
+	rec := request(h, http.MethodGet, "/api/games?player=A&player=C", "")
+	var got []analyzer.Game
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Errorf("GET /api/games?player=A&player=C returned %d games, want 2", len(got))
+	}
+	if rec := request(h, http.MethodGet, "/api/games?limit=0", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("bad filter: status %d, want 400", rec.Code)
+	}
+}
+
+func TestEditAndVoidGame(t *testing.T) {
+	s, h := newTestServer(t, testGame("1", 1, "A", "B", "C"), testGame("2", 2, "B", "C", "A"))
+
+	rec := request(h, http.MethodPut, "/api/games/1", `{"rankings": ["C", "B", "A"], "notes": "misreported"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PUT: status %d: %s", rec.Code, rec.Body)
+	}
+	c := decodeChange(t, rec)
+	if c.Game == nil || !slices.Equal(c.Game.Rankings, []string{"C", "B", "A"}) || c.Game.Notes != "misreported" || c.Game.Date != "3/1/2024" {
+		t.Fatalf("edited game = %+v", c.Game)
+	}
+	if len(c.Changes) == 0 {
+		t.Error("correcting the winner changed no ratings")
+	}
+
+	for _, tc := range []struct {
+		name, method, path, body string
+		status                   int
+	}{
+		{"unknown game", http.MethodPut, "/api/games/9", `{"notes": "x"}`, http.StatusNotFound},
+		{"unknown field", http.MethodPut, "/api/games/1", `{"winner": "C"}`, http.StatusBadRequest},
+		{"one player", http.MethodPut, "/api/games/1", `{"rankings": ["C"]}`, http.StatusBadRequest},
+		{"bad date", http.MethodPut, "/api/games/1", `{"date": "soon"}`, http.StatusBadRequest},
+		{"PATCH", http.MethodPatch, "/api/games/1", `{"notes": "x"}`, http.StatusMethodNotAllowed},
+		{"void unknown game", http.MethodDelete, "/api/games/9", "", http.StatusNotFound},
+	} {
+		if rec := request(h, tc.method, tc.path, tc.body); rec.Code != tc.status {
+			t.Errorf("%s: status %d, want %d: %s", tc.name, rec.Code, tc.status, rec.Body)
+		}
+	}
+	if g, _ := s.Ledger.Game("1"); g.Notes != "misreported" {
+		t.Errorf("a rejected edit changed the game: %+v", g)
+	}
+
+	rec = request(h, http.MethodDelete, "/api/games/2", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("DELETE: status %d: %s", rec.Code, rec.Body)
+	}
+	if c := decodeChange(t, rec); c.Game != nil || len(c.Changes) == 0 {
+		t.Errorf("void response = %+v, want no game and the rating changes", c)
+	}
+	if rec := request(h, http.MethodGet, "/api/games/2", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("GET a voided game: status %d, want 404", rec.Code)
+	}
+
+	rec = request(h, http.MethodGet, "/api/games/2/history", "")
+	var events []scoring.Event
+	if err := json.NewDecoder(rec.Body).Decode(&events); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].Type != scoring.Recorded || events[1].Type != scoring.Voided {
+		t.Errorf("history of a voided game = %+v", events)
+	}
+}
+
+func TestConfirmAndReject(t *testing.T) {
+	s, h := newTestServer(t)
+	pending := func(id string) {
+		t.Helper()
+		g := testGame(id, 1, "A", "B", "C")
+		g.Status = analyzer.Pending
+		if _, _, err := s.Ledger.Record(g); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pending("1")
+	pending("2")
+
+	for _, tc := range []struct {
+		name, path, body string
+		status           int
+		want             analyzer.GameStatus
+	}{
+		{"unknown game", "/api/games/9/confirm", `{"player": "A"}`, http.StatusNotFound, ""},
+		{"nobody named", "/api/games/1/confirm", "", http.StatusBadRequest, ""},
+		{"not JSON", "/api/games/1/confirm", `A`, http.StatusBadRequest, ""},
+		{"unknown field", "/api/games/1/confirm", `{"name": "A"}`, http.StatusBadRequest, ""},
+		{"not a player", "/api/games/1/confirm", `{"player": "D"}`, http.StatusForbidden, ""},
+		{"first player", "/api/games/1/confirm", `{"player": "A"}`, http.StatusOK, analyzer.Pending},
+		{"same player again", "/api/games/1/confirm", `{"player": "A"}`, http.StatusOK, analyzer.Pending},
+		{"quorum", "/api/games/1/confirm", `{"player": "B"}`, http.StatusOK, analyzer.Confirmed},
+		{"reject a scored game", "/api/games/1/reject", `{"player": "C"}`, http.StatusConflict, ""},
+		{"reject by a non-player", "/api/games/2/reject", `{"player": "D"}`, http.StatusForbidden, ""},
+		{"reject", "/api/games/2/reject", `{"player": "C"}`, http.StatusOK, analyzer.Rejected},
+		{"confirm a rejected game", "/api/games/2/confirm", `{"player": "A"}`, http.StatusConflict, ""},
+		{"scorekeeper approval", "/api/games/2/confirm", `{"player": "Sara", "approve": true}`, http.StatusOK, analyzer.Confirmed},
+	} {
+		rec := request(h, http.MethodPost, tc.path, tc.body)
+		if rec.Code != tc.status {
+			t.Errorf("%s: status %d, want %d: %s", tc.name, rec.Code, tc.status, rec.Body)
+			continue
+		}
+		if tc.want == "" {
+			continue
+		}
+		if c := decodeChange(t, rec); c.Game == nil || c.Game.Status != tc.want {
+			t.Errorf("%s: game %+v, want it %s", tc.name, c.Game, tc.want)
+		}
+	}
+	if g, _ := s.Ledger.Game("2"); g.ApprovedBy != "Sara" {
+		t.Errorf("approved game = %+v, want it approved by Sara", g)
+	}
+	if rec := request(h, http.MethodGet, "/api/games/1/confirm", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET confirm: status %d, want 405", rec.Code)
+	}
+}
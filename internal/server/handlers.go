@@ -1,50 +1,166 @@
 package server
 
 import (
-	"embed"
+	"bytes"
+	"context"
 	"encoding/json"
-	"html/template"
+	"maps"
 	"net/http"
-	"sort"
+	"slices"
+	"sync"
+	"time"
 
 	"github.com/dylanlott/guildmaster/internal/analyzer"
+	"github.com/dylanlott/guildmaster/internal/export"
+	"github.com/dylanlott/guildmaster/internal/schedule"
 	"github.com/dylanlott/guildmaster/internal/scoring"
+	"github.com/dylanlott/guildmaster/internal/sheets"
+	elogo "github.com/kortemy/elo-go"
 )
 
 type Server struct {
-	store *scoring.Store
-	K     int
-	D     float64
+	// ID and Name identify the league the server scores when it is one of
+	// several; see Leagues.
+	ID, Name string
+
+	store  scoring.Storage
+	source analyzer.GameSource
+	// K and D configure the Elo rating of games.
+	K int
+	D float64
+	// SeatAdjust adds the estimated seat advantage to expected scores when rating games.
+	SeatAdjust bool
+	// Quorum is how many of its players must confirm a game recorded through
+	// the API before it is scored; 0 means more than half of them.
+	Quorum int
+	// Dates parses the dates of edited games.
+	Dates analyzer.DateParser
+	// Ledger, when set, journals every change to the games so the store can be
+	// rebuilt by replaying them; see UseJournal.
+	Ledger *scoring.Ledger
+	// Schedule, when set, refreshes the scores in the background; see
+	// Leagues.RunSchedules.
+	Schedule *schedule.Scheduler
+
+	mu       sync.Mutex
+	warnings []analyzer.Warning // from the most recent load of the game source
+	refresh  RefreshStatus      // how the refreshes went, for GET /api/status
+	// the games from the last replay and the ratings after each of them, so
+	// only the games from the first one that changed are replayed
+	ratedGames  []analyzer.Game
+	checkpoints []map[string]int
+
+	events  broker   // clients of /api/events
+	leagues *Leagues // every league on the instance, for the landing page selector
 }
 
-func New(store *scoring.Store) *Server {
-	return &Server{store: store, K: 40, D: 800}
+func New(store scoring.Storage, source analyzer.GameSource) *Server {
+	return &Server{store: store, source: source, K: 40, D: 800}
 }
 
-// GET /api/scores - returns all current scores as JSON
+// GET /api/scores - returns all current scores as JSON, or as a ranked table
+// when CSV, Markdown or HTML is requested (see tableFormat)
 func (s *Server) HandleGetScores(w http.ResponseWriter, r *http.Request) {
 	scores := s.store.GetAll()
+	f, err := tableFormat(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if f != "" {
+		writeTable(w, export.Rankings(scores), f)
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(scores)
 }
 
-// RefreshAndPersistScores recomputes scores from Sheets and persists them into the in-memory store.
-func (s *Server) RefreshAndPersistScores() error {
-	snapshot, err := s.computeScoresFromGames()
+// tableFormat returns the table format a request asks for with ?format= or
+// its Accept header, or "" when the endpoint should answer with its usual JSON.
+func tableFormat(r *http.Request) (export.Format, error) {
+	f, ok := export.Negotiate(r.Header.Get("Accept"))
+	if name := r.URL.Query().Get("format"); name != "" {
+		var err error
+		if f, err = export.ParseFormat(name); err != nil {
+			return "", err
+		}
+		ok = true
+	}
+	if !ok || f == export.JSON {
+		return "", nil
+	}
+	return f, nil
+}
+
+func writeTable(w http.ResponseWriter, t export.Table, f export.Format) {
+	w.Header().Set("Content-Type", f.ContentType())
+	w.Header().Add("Vary", "Accept")
+	_ = export.Write(w, t, f)
+}
+
+// UseJournal replays journal into the server's store and sets s.Ledger, so
+// later refreshes journal what changed in the game source.
+func (s *Server) UseJournal(journal scoring.Journal) error {
+	l, err := scoring.NewLedger(journal, s.store, s.rateGames)
+	if err != nil {
+		return err
+	}
+	l.Quorum = s.Quorum
+	l.OnChange = s.onChange
+	s.Ledger = l
+	return nil
+}
+
+// RefreshAndPersistScores recomputes scores from the game source and persists them, with the games, into the store.
+func (s *Server) RefreshAndPersistScores(ctx context.Context) error {
+	games, err := s.loadGames(ctx)
+	if err == nil {
+		err = s.persist(games)
+	}
+	s.recordRefresh(len(games), err)
+	return err
+}
+
+// persist stores games and their ratings, through the ledger when there is one.
+func (s *Server) persist(games []analyzer.Game) error {
+	if s.Ledger != nil {
+		_, err := s.Ledger.Sync(games)
+		return err
+	}
+	snapshot, err := s.rateGames(games)
 	if err != nil {
 		return err
 	}
-	s.store.ReplaceAll(snapshot)
+	before := s.store.GetAll()
+	if err := s.store.ReplaceGames(games); err != nil {
+		return err
+	}
+	if err := s.store.ReplaceAll(snapshot); err != nil {
+		return err
+	}
+	if changes := scoring.DiffScores(before, snapshot); len(changes) > 0 {
+		s.publishChange(nil, changes)
+	}
 	return nil
 }
 
+// ScheduledRefresh is RefreshAndPersistScores for the background schedule.
+// It also fails when the source could only serve cached games, so the
+// schedule backs off until the source can be reached again.
+func (s *Server) ScheduledRefresh(ctx context.Context) error {
+	if err := s.RefreshAndPersistScores(ctx); err != nil {
+		return err
+	}
+	return s.sourceError()
+}
+
 // HandleRefresh recomputes and persists scores; returns the updated snapshot.
 func (s *Server) HandleRefresh(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	if err := s.RefreshAndPersistScores(); err != nil {
+	if err := s.RefreshAndPersistScores(r.Context()); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -52,89 +168,209 @@ func (s *Server) HandleRefresh(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(s.store.GetAll())
 }
 
-//go:embed landing.tmpl
-var tmplFS embed.FS
+// cacheStatuser is a game source that reports how fresh its data is.
+type cacheStatuser interface {
+	Status() sheets.CacheStatus
+}
 
-// computeScoresFromGames replays the games from Sheets (latest first) and returns a map of player->score
-func (s *Server) computeScoresFromGames() (map[string]int, error) {
-	games, err := fetchGameData()
+// loadGames fetches the games from the source in chronological order (oldest first).
+func (s *Server) loadGames(ctx context.Context) ([]analyzer.Game, error) {
+	games, warnings, err := analyzer.LoadGames(ctx, s.source)
 	if err != nil {
 		return nil, err
 	}
-	// replay games in chronological order (oldest first)
-	sort.Slice(games, func(i, j int) bool { return games[i].Timestamp.Before(games[j].Timestamp) })
-	// snapshot holds absolute ratings (1500 default)
-	snapshot := make(map[string]int)
+	s.mu.Lock()
+	s.warnings = warnings
+	s.mu.Unlock()
+	analyzer.SortChronologically(games)
+	return games, nil
+}
+
+// currentGames returns the scored games in chronological order, including
+// any edits, as of the last refresh. Reads never refresh from the game
+// source: only POST /api/refresh, the startup refresh and the schedule do.
+func (s *Server) currentGames() []analyzer.Game {
+	if s.Ledger != nil {
+		return s.Ledger.Games()
+	}
+	return s.store.Games()
+}
+
+// elo returns the rating system configured by K and D.
+func (s *Server) elo() *elogo.Elo {
 	elo := analyzer.InitializeElo()
-	for _, g := range games {
-		if len(g.Rankings) < 2 {
-			continue
-		}
-		// reuse analyzer.ScoreGame which mutates the provided scores map
-		if err := analyzer.ScoreGame(elo, snapshot, g.Rankings); err != nil {
+	elo.K = s.K
+	elo.D = int(s.D)
+	return elo
+}
+
+// rateGames returns the ratings after games. Games up to the first one that
+// differs from the last call are not replayed again.
+func (s *Server) rateGames(games []analyzer.Game) (map[string]int, error) {
+	elo := s.elo()
+	if s.SeatAdjust {
+		// the seat advantage is estimated from every game, so a new game can change how all of them are rated
+		adv := analyzer.EstimateSeatAdvantage(games, float64(elo.D))
+		return analyzer.RateGames(elo, games, adv)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for n < len(s.ratedGames) && n < len(games) && sameGame(s.ratedGames[n], games[n]) {
+		n++
+	}
+	// every player starts at the default 1500 rating
+	scores := make(map[string]int)
+	if n > 0 {
+		maps.Copy(scores, s.checkpoints[n-1])
+	}
+	checkpoints := slices.Clone(s.checkpoints[:n])
+	for _, g := range games[n:] {
+		if err := analyzer.ReplayGames(elo, scores, []analyzer.Game{g}, nil); err != nil {
 			return nil, err
 		}
+		checkpoints = append(checkpoints, maps.Clone(scores))
 	}
-	return snapshot, nil
+	s.ratedGames = slices.Clone(games)
+	s.checkpoints = checkpoints
+	return scores, nil
+}
+
+// sameGame reports whether a and b record the same result.
+func sameGame(a, b analyzer.Game) bool {
+	return a.ID == b.ID && a.Timestamp.Equal(b.Timestamp) && slices.Equal(a.Rankings, b.Rankings)
 }
 
 // HandleLanding renders the embedded landing template with computed scores and recent games
 func (s *Server) HandleLanding(w http.ResponseWriter, r *http.Request) {
-	t, err := template.New("landing.tmpl").Funcs(template.FuncMap{
-		"add1": func(i int) int { return i + 1 },
-		"medal": func(i int) string {
-			switch i {
-			case 0:
-				return "🥇"
-			case 1:
-				return "🥈"
-			case 2:
-				return "🥉"
-			default:
-				return ""
-			}
-		},
-	}).ParseFS(tmplFS, "landing.tmpl")
-	if err != nil {
-		http.Error(w, "template parse error: "+err.Error(), http.StatusInternalServerError)
-		return
+	page := NewLandingPage(s.currentGames(), s.store.GetAll())
+	page.Refreshed = s.RefreshStatus().LastSuccess
+	page.Title, page.APIBase = s.Name, s.apiBase()
+	if s.leagues != nil && len(s.leagues.list) > 1 {
+		page.Leagues = s.leagues.links(s)
 	}
-	games, _ := fetchGameData() // ignore error here and show empty games if fails
-	// sort games by timestamp desc and keep only latest 10
-	sort.Slice(games, func(i, j int) bool { return games[i].Timestamp.After(games[j].Timestamp) })
-	if len(games) > 10 {
-		games = games[:10]
+	page.Locked = s.leagues != nil && s.leagues.Tokens != nil
+	page.Pending = s.pendingGames()
+	if c, ok := s.source.(cacheStatuser); ok {
+		st := c.Status()
+		page.Cache = &st
+		page.CacheAge = time.Since(st.FetchedAt).Round(time.Minute).String()
 	}
-
-	scoresMap, _ := s.computeScoresFromGames()
-	// build ranked slice sorted by score desc, then name asc for stability
-	type scoreRow struct {
-		Name  string
-		Score int
+	var buf bytes.Buffer
+	if err := RenderLanding(&buf, page); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
-	ranked := make([]scoreRow, 0, len(scoresMap))
-	for name, sc := range scoresMap {
-		ranked = append(ranked, scoreRow{Name: name, Score: sc})
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = buf.WriteTo(w)
+}
+
+// pendingGames returns the games awaiting confirmation, oldest first.
+func (s *Server) pendingGames() []PendingGame {
+	pending := []PendingGame{}
+	if s.Ledger == nil {
+		return pending
 	}
-	sort.SliceStable(ranked, func(i, j int) bool {
-		if ranked[i].Score == ranked[j].Score {
-			return ranked[i].Name < ranked[j].Name
+	for _, g := range s.Ledger.AllGames() {
+		if g.Status == analyzer.Pending {
+			pending = append(pending, PendingGame{Game: g, Needed: s.Ledger.ConfirmationsNeeded(g)})
 		}
-		return ranked[i].Score > ranked[j].Score
-	})
+	}
+	return pending
+}
 
-	data := struct {
-		Games       []*Game
-		Ranked      []scoreRow
-		PlayerCount int
+// GET /api/stats - returns seat, elimination and turn statistics from the game metadata
+func (s *Server) HandleGetStats(w http.ResponseWriter, r *http.Request) {
+	all := s.currentGames()
+	elo := s.elo()
+	adv := analyzer.EstimateSeatAdvantage(all, float64(elo.D))
+	adjustment, err := analyzer.CompareSeatAdjustment(elo, all, adv)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	type podRow struct {
+		analyzer.PodSeatStats
+		Advantage []float64 `json:"advantage"`
+	}
+	pods := []podRow{}
+	for _, p := range analyzer.SeatWinRatesByPodSize(all) {
+		pods = append(pods, podRow{PodSeatStats: p, Advantage: adv[p.PodSize]})
+	}
+	type seatRow struct {
+		analyzer.SeatStat
+		WinRate float64 `json:"win_rate"`
+	}
+	seats := []seatRow{}
+	for _, st := range analyzer.SeatWinRates(all) {
+		seats = append(seats, seatRow{SeatStat: st, WinRate: st.WinRate()})
+	}
+	stats := struct {
+		Games        int                        `json:"games"`
+		AverageTurns float64                    `json:"average_turns"`
+		Seats        []seatRow                  `json:"seats"`
+		PodSeats     []podRow                   `json:"pod_seats"`
+		Adjustment   []analyzer.SeatAdjustment  `json:"seat_adjustment"`
+		SeatAdjusted bool                       `json:"seat_adjusted"`
+		Eliminations []analyzer.EliminationStat `json:"eliminations"`
 	}{
-		Games:       games,
-		Ranked:      ranked,
-		PlayerCount: len(ranked),
+		Games:        len(all),
+		AverageTurns: analyzer.AverageTurns(all),
+		Seats:        seats,
+		PodSeats:     pods,
+		Adjustment:   adjustment,
+		SeatAdjusted: s.SeatAdjust,
+		Eliminations: analyzer.EliminationStats(all),
 	}
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	if err := t.Execute(w, data); err != nil {
-		http.Error(w, "template execute error: "+err.Error(), http.StatusInternalServerError)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(stats)
+}
+
+// /api/games - GET lists the games (HandleGetGames) and POST records one (HandleCreateGame)
+func (s *Server) HandleGames(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.HandleGetGames(w, r)
+	case http.MethodPost:
+		s.HandleCreateGame(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GET /api/games - returns the games, oldest first, as JSON, or as a
+// history table when CSV, Markdown or HTML is requested. See filterGames for
+// the query parameters that narrow them down.
+func (s *Server) HandleGetGames(w http.ResponseWriter, r *http.Request) {
+	f, err := tableFormat(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	games := s.currentGames()
+	if s.Ledger != nil {
+		// include the games that aren't scored, for ?status=
+		games = s.Ledger.AllGames()
+	}
+	games, err = filterGames(games, r.URL.Query(), s.Dates)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if f != "" {
+		writeTable(w, export.History(games), f)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(games)
+}
+
+// GET /api/warnings - lists games the source could not fully parse on its most recent load
+func (s *Server) HandleGetWarnings(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	warnings := append([]analyzer.Warning{}, s.warnings...)
+	s.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(warnings)
 }
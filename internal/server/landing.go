@@ -0,0 +1,135 @@
+package server
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"io"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/dylanlott/guildmaster/internal/analyzer"
+	"github.com/dylanlott/guildmaster/internal/sheets"
+)
+
+//go:embed landing.tmpl
+var tmplFS embed.FS
+
+// ScoreRow is one line of the landing page scoreboard.
+type ScoreRow struct {
+	Name  string
+	Score int
+}
+
+// LandingPage is the data rendered by the landing template.
+type LandingPage struct {
+	// Title names the league; empty shows just "Guildmaster".
+	Title string
+	Games []analyzer.Game // most recent first
+	// Pending lists the games waiting for their players to confirm them, oldest first.
+	Pending     []PendingGame
+	Ranked      []ScoreRow
+	PlayerCount int
+	// Cache describes the freshness of a cached Sheets feed, when there is one.
+	Cache    *sheets.CacheStatus
+	CacheAge string
+	// Refreshed is when the scores were last refreshed from the game source,
+	// when the server knows.
+	Refreshed time.Time
+	// Static renders the page for a static site: players link to their pages
+	// and controls that need the server are left out.
+	Static bool
+	// PlayerPages names each player's page on a static site, without the extension.
+	PlayerPages map[string]string
+	// APIBase is the path of the league's API; empty means /api.
+	APIBase string
+	// Locked leaves out controls that need an API token.
+	Locked bool
+	// Leagues lists every league on the server for the selector; it is
+	// left out when empty.
+	Leagues []LeagueLink
+}
+
+// PendingGame is a game on the landing page's list of games awaiting confirmation.
+type PendingGame struct {
+	analyzer.Game
+	// Needed is how many players must confirm it.
+	Needed int `json:"needed"`
+}
+
+// LeagueLink is an entry in the landing page's league selector.
+type LeagueLink struct {
+	Name    string
+	URL     string
+	Current bool
+}
+
+// NewLandingPage builds the landing page for games in chronological order
+// and the ratings they produced, keeping the latest 10 games.
+func NewLandingPage(games []analyzer.Game, scores map[string]int) LandingPage {
+	recent := recentGames(games)
+	// ranked by score desc, then name asc for stability
+	ranked := make([]ScoreRow, 0, len(scores))
+	for _, fs := range analyzer.CalculateFinalScores(scores) {
+		ranked = append(ranked, ScoreRow{Name: fs.Player, Score: fs.EloScore})
+	}
+	return LandingPage{Games: recent, Ranked: ranked, PlayerCount: len(ranked)}
+}
+
+// recentGames returns the latest 10 of games in chronological order, newest first.
+func recentGames(games []analyzer.Game) []analyzer.Game {
+	recent := append([]analyzer.Game{}, games...)
+	slices.Reverse(recent)
+	if len(recent) > 10 {
+		recent = recent[:10]
+	}
+	return recent
+}
+
+// RenderLanding executes the landing template for page.
+func RenderLanding(w io.Writer, page LandingPage) error {
+	t, err := template.New("landing.tmpl").Funcs(TemplateFuncs).ParseFS(tmplFS, "landing.tmpl")
+	if err != nil {
+		return fmt.Errorf("template parse error: %w", err)
+	}
+	if err := t.Execute(w, page); err != nil {
+		return fmt.Errorf("template execute error: %w", err)
+	}
+	return nil
+}
+
+// TemplateFuncs are the helpers available to the landing template.
+var TemplateFuncs = template.FuncMap{
+	"add1": func(i int) int { return i + 1 },
+	"medal": func(i int) string {
+		switch i {
+		case 0:
+			return "🥇"
+		case 1:
+			return "🥈"
+		case 2:
+			return "🥉"
+		default:
+			return ""
+		}
+	},
+	"slug": Slug,
+}
+
+// Slug turns a player name into a file name safe string, e.g. "Dylan/Sara" becomes "dylan-sara".
+func Slug(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
@@ -0,0 +1,216 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/dylanlott/guildmaster/internal/auth"
+)
+
+// Leagues hosts several leagues on one server, each a Server with its own
+// game source, store and rating config. The first league is the default: the
+// unprefixed routes such as /api/scores serve it.
+type Leagues struct {
+	// Tokens, when set, protects every route that changes anything: see
+	// requiredRole. Without it the API is open to anyone.
+	Tokens *auth.Tokens
+	// Private also requires a viewer token to read scores and games,
+	// including the landing pages and the event stream, which browsers can't
+	// then open: a private server is for API clients only.
+	Private bool
+
+	list []*Server
+	byID map[string]*Server
+}
+
+// NewLeagues groups servers into leagues. Every server needs a unique ID.
+func NewLeagues(servers ...*Server) (*Leagues, error) {
+	if len(servers) == 0 {
+		return nil, errors.New("no leagues")
+	}
+	ls := &Leagues{list: servers, byID: make(map[string]*Server)}
+	for _, s := range servers {
+		if s.ID == "" {
+			return nil, errors.New("league has no ID")
+		}
+		if ls.byID[s.ID] != nil {
+			return nil, fmt.Errorf("league %s is listed more than once", s.ID)
+		}
+		ls.byID[s.ID] = s
+		s.leagues = ls
+	}
+	return ls, nil
+}
+
+// All returns the leagues in order, the default first.
+func (ls *Leagues) All() []*Server {
+	return ls.list
+}
+
+// Get returns the league with the given ID.
+func (ls *Leagues) Get(id string) (*Server, bool) {
+	s, ok := ls.byID[id]
+	return s, ok
+}
+
+// Register adds every route to mux. Each API route is served for the default
+// league under /api and for every league under /api/leagues/{league}; the
+// landing page is at / and /leagues/{league}/.
+func (ls *Leagues) Register(mux *http.ServeMux) {
+	routes := map[string]func(*Server, http.ResponseWriter, *http.Request){
+		"/scores":             (*Server).HandleGetScores,
+		"/refresh":            (*Server).HandleRefresh,
+		"/stats":              (*Server).HandleGetStats,
+		"/warnings":           (*Server).HandleGetWarnings,
+		"/games":              (*Server).HandleGames,
+		"/game":               (*Server).HandleCreateGame,
+		"/games/{id}":         (*Server).HandleGame,
+		"/games/{id}/history": (*Server).HandleGameHistory,
+		"/events":             (*Server).HandleEvents,
+		"/status":             (*Server).HandleStatus,
+	}
+	for path, handle := range routes {
+		mux.HandleFunc("/api"+path, ls.route(handle))
+		mux.HandleFunc("/api/leagues/{league}"+path, ls.route(handle))
+	}
+	// players confirm games with their own tokens, whatever their role
+	signed := map[string]func(*Server, http.ResponseWriter, *http.Request){
+		"/games/{id}/confirm": (*Server).HandleConfirm,
+		"/games/{id}/reject":  (*Server).HandleReject,
+	}
+	for path, handle := range signed {
+		mux.HandleFunc("/api"+path, ls.routeSigned(handle))
+		mux.HandleFunc("/api/leagues/{league}"+path, ls.routeSigned(handle))
+	}
+	mux.HandleFunc("/api/leagues", ls.route(func(_ *Server, w http.ResponseWriter, r *http.Request) { ls.HandleList(w, r) }))
+	mux.HandleFunc("/leagues/{league}/{$}", ls.route((*Server).HandleLanding))
+	mux.HandleFunc("/", ls.route((*Server).HandleLanding))
+}
+
+// route returns a handler calling handle on the league named by the
+// {league} path value, or the default league when there is none, once the
+// request's token allows it.
+func (ls *Leagues) route(handle func(*Server, http.ResponseWriter, *http.Request)) http.HandlerFunc {
+	return ls.serve(handle, func(method string) (auth.Role, bool) {
+		role := requiredRole(method)
+		return role, role != auth.Viewer || ls.Private
+	})
+}
+
+// routeSigned is route for endpoints that act for whoever holds the token,
+// such as confirming a game: any role will do, but a token is needed
+// whenever the server checks them.
+func (ls *Leagues) routeSigned(handle func(*Server, http.ResponseWriter, *http.Request)) http.HandlerFunc {
+	return ls.serve(handle, func(string) (auth.Role, bool) { return auth.Viewer, true })
+}
+
+// serve returns a handler calling handle on the request's league, checking
+// its token first when access says one is needed for the request method.
+func (ls *Leagues) serve(handle func(*Server, http.ResponseWriter, *http.Request), access func(method string) (auth.Role, bool)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s := ls.list[0]
+		if id := r.PathValue("league"); id != "" {
+			var ok bool
+			if s, ok = ls.byID[id]; !ok {
+				http.Error(w, fmt.Sprintf("no league %q", id), http.StatusNotFound)
+				return
+			}
+		}
+		var h http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { handle(s, w, r) })
+		if role, needed := access(r.Method); ls.Tokens != nil && needed {
+			h = ls.Tokens.Require(role, h)
+		}
+		h.ServeHTTP(w, r)
+	}
+}
+
+// requiredRole is the role a token needs for a request method: reads need a
+// viewer, recording and correcting games or refreshing need a scorekeeper
+// and voiding games needs an admin.
+func requiredRole(method string) auth.Role {
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		return auth.Viewer
+	case http.MethodPost, http.MethodPut:
+		return auth.Scorekeeper
+	default:
+		return auth.Admin
+	}
+}
+
+// GET /api/leagues - lists the leagues with their player and game counts
+func (ls *Leagues) HandleList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	type league struct {
+		ID      string  `json:"id"`
+		Name    string  `json:"name"`
+		Players int     `json:"players"`
+		Games   int     `json:"games"`
+		K       int     `json:"k"`
+		D       float64 `json:"d"`
+	}
+	out := make([]league, 0, len(ls.list))
+	for _, s := range ls.list {
+		out = append(out, league{ID: s.ID, Name: s.Name, Players: len(s.store.GetAll()), Games: len(s.store.Games()), K: s.K, D: s.D})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(out)
+}
+
+// RefreshAll refreshes every league, returning the errors of those that failed.
+func (ls *Leagues) RefreshAll(ctx context.Context) error {
+	var errs []error
+	for _, s := range ls.list {
+		if err := s.RefreshAndPersistScores(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("league %s: %w", s.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RunSchedules refreshes every league with a Schedule in the background
+// until ctx is done, returning once they have all stopped.
+func (ls *Leagues) RunSchedules(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, s := range ls.list {
+		if s.Schedule == nil {
+			continue
+		}
+		wg.Go(func() {
+			s.Schedule.Run(ctx, func(runCtx context.Context) error {
+				err := s.ScheduledRefresh(runCtx)
+				if err != nil && ctx.Err() == nil {
+					log.Printf("league %s: scheduled refresh failed, next in %v: %v", s.ID, s.Schedule.Delay(s.Schedule.Failures()+1), err)
+				}
+				return err
+			})
+		})
+	}
+	wg.Wait()
+}
+
+// links returns the league selector for the landing page of current.
+func (ls *Leagues) links(current *Server) []LeagueLink {
+	links := make([]LeagueLink, len(ls.list))
+	for i, s := range ls.list {
+		links[i] = LeagueLink{Name: s.Name, URL: "/leagues/" + url.PathEscape(s.ID) + "/", Current: s == current}
+	}
+	return links
+}
+
+// apiBase returns the path the landing page calls the league's API under.
+func (s *Server) apiBase() string {
+	if s.leagues == nil || s == s.leagues.list[0] {
+		return "/api"
+	}
+	return "/api/leagues/" + url.PathEscape(s.ID)
+}
@@ -0,0 +1,241 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dylanlott/guildmaster/internal/analyzer"
+	"github.com/dylanlott/guildmaster/internal/auth"
+	"github.com/dylanlott/guildmaster/internal/scoring"
+)
+
+func TestLeagues(t *testing.T) {
+	games := []analyzer.Game{testGame("1", 1, "A", "B", "C"), testGame("2", 2, "B", "C", "A"), testGame("3", 3, "A", "C", "B")}
+	var servers []*Server
+	for _, l := range []struct {
+		id string
+		k  int
+		d  float64
+	}{{"pod", 40, 800}, {"ladder", 16, 400}} {
+		s := New(scoring.NewStore(), &testSource{games: games})
+		s.ID, s.K, s.D = l.id, l.k, l.d
+		if err := s.UseJournal(scoring.NewMemoryJournal()); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.RefreshAndPersistScores(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		servers = append(servers, s)
+	}
+	ls, err := NewLeagues(servers...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	ls.Register(mux)
+
+	scores := func(path string) map[string]int {
+		t.Helper()
+		rec := request(mux, http.MethodGet, path, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status %d: %s", path, rec.Code, rec.Body)
+		}
+		var scores map[string]int
+		if err := json.NewDecoder(rec.Body).Decode(&scores); err != nil {
+			t.Fatal(err)
+		}
+		return scores
+	}
+	for i, s := range servers {
+		elo := analyzer.InitializeElo()
+		elo.K, elo.D = s.K, int(s.D)
+		want, err := analyzer.RateGames(elo, games, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := scores("/api/leagues/" + s.ID + "/scores"); !maps.Equal(got, want) {
+			t.Errorf("league %s rated with K %d, D %v: got %v, want %v", s.ID, s.K, s.D, got, want)
+		}
+		if i == 0 && !maps.Equal(scores("/api/scores"), want) {
+			t.Errorf("/api/scores doesn't serve the default league %s", s.ID)
+		}
+	}
+	if maps.Equal(scores("/api/leagues/pod/scores"), scores("/api/leagues/ladder/scores")) {
+		t.Error("leagues with different K and D rated the same games alike")
+	}
+
+	// a game recorded in one league stays out of the others
+	pod := scores("/api/leagues/pod/scores")
+	if rec := request(mux, http.MethodPost, "/api/leagues/ladder/game", `{"id": "x1", "players": ["D", "A"]}`); rec.Code != http.StatusCreated {
+		t.Fatalf("recording in a league: status %d: %s", rec.Code, rec.Body)
+	}
+	for _, player := range []string{"D", "A"} {
+		if rec := request(mux, http.MethodPost, "/api/leagues/ladder/games/x1/confirm", `{"player": "`+player+`"}`); rec.Code != http.StatusOK {
+			t.Fatalf("confirming in a league: status %d: %s", rec.Code, rec.Body)
+		}
+	}
+	if _, ok := scores("/api/leagues/ladder/scores")["D"]; !ok {
+		t.Error("the league's scores left out the game recorded in it")
+	}
+	if got := scores("/api/leagues/pod/scores"); !maps.Equal(got, pod) {
+		t.Errorf("recording in another league changed pod's scores: got %v, want %v", got, pod)
+	}
+	if rec := request(mux, http.MethodGet, "/api/leagues/pod/games/x1", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("another league's game: status %d, want 404", rec.Code)
+	}
+
+	rec := request(mux, http.MethodGet, "/api/leagues", "")
+	var list []struct {
+		ID    string `json:"id"`
+		Games int    `json:"games"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].ID != "pod" || list[0].Games != 3 || list[1].ID != "ladder" || list[1].Games != 4 {
+		t.Errorf("GET /api/leagues = %+v", list)
+	}
+
+	for _, path := range []string{"/api/leagues/cube/scores", "/api/leagues/cube/games/1", "/leagues/cube/"} {
+		if rec := request(mux, http.MethodGet, path, ""); rec.Code != http.StatusNotFound {
+			t.Errorf("%s: status %d, want 404", path, rec.Code)
+		}
+	}
+
+	if _, err := NewLeagues(New(scoring.NewStore(), nil)); err == nil {
+		t.Error("NewLeagues accepted a league without an ID")
+	}
+	if _, err := NewLeagues(servers[0], servers[0]); err == nil {
+		t.Error("NewLeagues accepted a league listed twice")
+	}
+}
+
+func TestRequiredRole(t *testing.T) {
+	for method, want := range map[string]auth.Role{
+		http.MethodGet:    auth.Viewer,
+		http.MethodHead:   auth.Viewer,
+		http.MethodPost:   auth.Scorekeeper,
+		http.MethodPut:    auth.Scorekeeper,
+		http.MethodDelete: auth.Admin,
+		http.MethodPatch:  auth.Admin,
+	} {
+		if got := requiredRole(method); got != want {
+			t.Errorf("requiredRole(%s) = %s, want %s", method, got, want)
+		}
+	}
+}
+
+// newAuthServer returns a league checking tokens, and the secrets of tokens
+// named after their holders: player A and Vic are viewers, Sara a scorekeeper
+// and Ada an admin.
+func newAuthServer(t *testing.T, private bool) (*Server, http.Handler, map[string]string) {
+	t.Helper()
+	s, _ := newTestServer(t, testGame("1", 1, "A", "B", "C"), testGame("2", 2, "B", "C", "A"))
+	tokens, err := auth.Open(filepath.Join(t.TempDir(), "tokens.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	secrets := make(map[string]string)
+	for name, role := range map[string]auth.Role{"A": auth.Viewer, "Vic": auth.Viewer, "Sara": auth.Scorekeeper, "Ada": auth.Admin} {
+		if secrets[name], _, err = tokens.Mint(name, role); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ls, err := NewLeagues(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ls.Tokens, ls.Private = tokens, private
+	mux := http.NewServeMux()
+	ls.Register(mux)
+	return s, mux, secrets
+}
+
+// requestAs serves a request through h with the given token secret, if any.
+func requestAs(h http.Handler, secret, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if secret != "" {
+		req.Header.Set("Authorization", "Bearer "+secret)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAccessControl(t *testing.T) {
+	s, h, secrets := newAuthServer(t, false)
+	g := testGame("p", 3, "A", "B", "C")
+	g.Status = analyzer.Pending
+	if _, _, err := s.Ledger.Record(g); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name, token, method, path, body string
+		status                          int
+	}{
+		{"read without a token", "", http.MethodGet, "/api/scores", "", http.StatusOK},
+		{"read a league without a token", "", http.MethodGet, "/api/leagues/test/games", "", http.StatusOK},
+		{"record without a token", "", http.MethodPost, "/api/game", `{"players": ["A", "B"]}`, http.StatusUnauthorized},
+		{"record with an unknown token", "gm_nope", http.MethodPost, "/api/game", `{"players": ["A", "B"]}`, http.StatusUnauthorized},
+		{"record as a viewer", "A", http.MethodPost, "/api/game", `{"players": ["A", "B"]}`, http.StatusForbidden},
+		{"record as a scorekeeper", "Sara", http.MethodPost, "/api/game", `{"players": ["A", "B"]}`, http.StatusCreated},
+		{"refresh without a token", "", http.MethodPost, "/api/refresh", "", http.StatusUnauthorized},
+		{"refresh a league without a token", "", http.MethodPost, "/api/leagues/test/refresh", "", http.StatusUnauthorized},
+		{"refresh as a scorekeeper", "Sara", http.MethodPost, "/api/refresh", "", http.StatusOK},
+		{"correct as a viewer", "Vic", http.MethodPut, "/api/games/2", `{"notes": "x"}`, http.StatusForbidden},
+		{"correct as a scorekeeper", "Sara", http.MethodPut, "/api/games/2", `{"notes": "x"}`, http.StatusOK},
+		{"void as a scorekeeper", "Sara", http.MethodDelete, "/api/games/1", "", http.StatusForbidden},
+		{"void as an admin", "Ada", http.MethodDelete, "/api/games/1", "", http.StatusOK},
+		{"confirm without a token", "", http.MethodPost, "/api/games/p/confirm", `{"player": "A"}`, http.StatusUnauthorized},
+		{"confirm as a viewer who didn't play", "Vic", http.MethodPost, "/api/games/p/confirm", "", http.StatusForbidden},
+		{"approve as a viewer", "A", http.MethodPost, "/api/games/p/confirm", `{"approve": true}`, http.StatusForbidden},
+		{"confirm as a viewer who played", "A", http.MethodPost, "/api/games/p/confirm", "", http.StatusOK},
+		{"reject as a viewer who didn't play", "Vic", http.MethodPost, "/api/games/p/reject", "", http.StatusForbidden},
+		{"approve as a scorekeeper", "Sara", http.MethodPost, "/api/games/p/confirm", "", http.StatusOK},
+	} {
+		secret := secrets[tc.token]
+		if secret == "" {
+			secret = tc.token
+		}
+		if rec := requestAs(h, secret, tc.method, tc.path, tc.body); rec.Code != tc.status {
+			t.Errorf("%s: status %d, want %d: %s", tc.name, rec.Code, tc.status, rec.Body)
+		}
+	}
+	if g, _ := s.Ledger.Game("2"); g.Notes != "x" {
+		t.Errorf("game = %+v, want the scorekeeper's correction", g)
+	}
+	if _, ok := s.Ledger.Game("1"); ok {
+		t.Error("the admin's void didn't remove the game")
+	}
+	g, _ = s.Ledger.Game("p")
+	if g.Status != analyzer.Confirmed || len(g.ConfirmedBy) != 1 || g.ConfirmedBy[0] != "A" || g.ApprovedBy != "Sara" {
+		t.Errorf("game = %+v, want it confirmed by A and approved by Sara", g)
+	}
+}
+
+func TestPrivate(t *testing.T) {
+	_, h, secrets := newAuthServer(t, true)
+	for _, tc := range []struct {
+		name, token, path string
+		status            int
+	}{
+		{"scores without a token", "", "/api/scores", http.StatusUnauthorized},
+		{"games of a league without a token", "", "/api/leagues/test/games", http.StatusUnauthorized},
+		{"landing page without a token", "", "/", http.StatusUnauthorized},
+		{"league list without a token", "", "/api/leagues", http.StatusUnauthorized},
+		{"event stream without a token", "", "/api/events", http.StatusUnauthorized},
+		{"landing page as a viewer", "Vic", "/", http.StatusOK},
+		{"scores as a viewer", "Vic", "/api/scores", http.StatusOK},
+		{"status as a viewer", "Vic", "/api/status", http.StatusOK},
+	} {
+		if rec := requestAs(h, secrets[tc.token], http.MethodGet, tc.path, ""); rec.Code != tc.status {
+			t.Errorf("%s: status %d, want %d: %s", tc.name, rec.Code, tc.status, rec.Body)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// RefreshStatus reports how refreshing the scores from the game source has
+// gone, whether refreshes were scheduled, requested through the API or run
+// at startup.
+type RefreshStatus struct {
+	LastAttempt time.Time `json:"last_attempt,omitzero"`
+	// LastSuccess is when the game source was last read, not counting reads
+	// served from a cache because the source couldn't be reached.
+	LastSuccess time.Time `json:"last_success,omitzero"`
+	// LastError is why the latest failed refresh failed; it is kept after
+	// later refreshes succeed.
+	LastError   string    `json:"last_error,omitempty"`
+	LastErrorAt time.Time `json:"last_error_at,omitzero"`
+	// Failures counts the refreshes that have failed in a row.
+	Failures int `json:"failures"`
+	// Games is how many games the last refresh that got any loaded.
+	Games int `json:"games"`
+	// Interval and NextRefresh describe the background schedule, when there is one.
+	Interval    string    `json:"interval,omitempty"`
+	NextRefresh time.Time `json:"next_refresh,omitzero"`
+}
+
+// recordRefresh records the outcome of a refresh that loaded games and
+// failed with err, if it did.
+func (s *Server) recordRefresh(games int, err error) {
+	if err == nil {
+		err = s.sourceError()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now().UTC()
+	st := &s.refresh
+	st.LastAttempt = now
+	if games > 0 || err == nil {
+		st.Games = games
+	}
+	if err != nil {
+		st.LastError, st.LastErrorAt = err.Error(), now
+		st.Failures++
+		return
+	}
+	st.LastSuccess, st.Failures = now, 0
+}
+
+// sourceError returns why the game source served cached games, if it did
+// because it couldn't be reached.
+func (s *Server) sourceError() error {
+	c, ok := s.source.(cacheStatuser)
+	if !ok {
+		return nil
+	}
+	if st := c.Status(); st.Cached && st.Error != "" {
+		return errors.New("game source unreachable, using cached games: " + st.Error)
+	}
+	return nil
+}
+
+// RefreshStatus returns how refreshing the scores has gone.
+func (s *Server) RefreshStatus() RefreshStatus {
+	s.mu.Lock()
+	st := s.refresh
+	s.mu.Unlock()
+	if s.Schedule != nil {
+		st.Interval = s.Schedule.Interval.String()
+		st.NextRefresh = s.Schedule.Next().UTC()
+	}
+	return st
+}
+
+// GET /api/status - reports the last successful refresh from the game
+// source, the last error and how many games were loaded (see RefreshStatus)
+func (s *Server) HandleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(s.RefreshStatus())
+}
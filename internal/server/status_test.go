@@ -0,0 +1,59 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/dylanlott/guildmaster/internal/schedule"
+)
+
+func TestStatus(t *testing.T) {
+	s, h := newTestServer(t, testGame("1", 1, "A", "B"), testGame("2", 2, "B", "A"))
+	status := func() RefreshStatus {
+		t.Helper()
+		rec := request(h, http.MethodGet, "/api/status", "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status %d: %s", rec.Code, rec.Body)
+		}
+		var st RefreshStatus
+		if err := json.NewDecoder(rec.Body).Decode(&st); err != nil {
+			t.Fatal(err)
+		}
+		return st
+	}
+
+	st := status()
+	if st.Games != 2 || st.Failures != 0 || st.LastSuccess.IsZero() || st.LastError != "" || st.Interval != "" {
+		t.Fatalf("status after the first refresh = %+v", st)
+	}
+	success := st.LastSuccess
+
+	s.source.(*testSource).fail(errors.New("sheet unreachable"))
+	for range 2 {
+		if err := s.RefreshAndPersistScores(context.Background()); err == nil {
+			t.Fatal("refresh from a failing source succeeded")
+		}
+	}
+	st = status()
+	if st.Failures != 2 || st.LastError != "sheet unreachable" || st.LastErrorAt.IsZero() || !st.LastSuccess.Equal(success) || st.Games != 2 {
+		t.Fatalf("status after failed refreshes = %+v", st)
+	}
+
+	s.source.(*testSource).fail(nil)
+	s.Schedule = &schedule.Scheduler{Interval: time.Minute}
+	if err := s.ScheduledRefresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	st = status()
+	if st.Failures != 0 || st.LastError != "sheet unreachable" || !st.LastSuccess.After(success) || st.Interval != "1m0s" {
+		t.Fatalf("status after recovering = %+v", st)
+	}
+
+	if rec := request(h, http.MethodPost, "/api/status", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST /api/status: status %d, want 405", rec.Code)
+	}
+}
@@ -0,0 +1,231 @@
+package sheets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dylanlott/guildmaster/internal/analyzer"
+)
+
+// DefaultResync is how often CachedSource refetches the whole range to pick
+// up edits to rows it has already cached.
+const DefaultResync = time.Hour
+
+// CachedSource keeps a copy of the raw sheet rows on disk. After the first
+// fetch only the last cached row and the rows below it are requested; when
+// that row has changed, rows were inserted or deleted above it and the whole
+// range is fetched again. When Sheets can't be reached the cached rows are
+// served instead.
+type CachedSource struct {
+	Source
+	// Path is the cache file.
+	Path string
+	// Offline serves the cache without contacting Sheets.
+	Offline bool
+	// Resync is how old the last full fetch may get before the whole range is
+	// fetched again; zero uses DefaultResync.
+	Resync time.Duration
+
+	mu     sync.Mutex
+	cache  *sheetCache
+	status CacheStatus
+}
+
+// CacheStatus describes the data most recently returned by a CachedSource.
+type CacheStatus struct {
+	// FetchedAt is when the rows were last fetched from Sheets.
+	FetchedAt time.Time `json:"fetched_at"`
+	// Cached is set when the rows came from the cache without reaching Sheets.
+	Cached bool `json:"cached"`
+	// Error is why Sheets couldn't be reached, if it was tried.
+	Error string `json:"error,omitempty"`
+}
+
+// sheetCache is the on-disk cache file.
+type sheetCache struct {
+	SpreadsheetID string          `json:"spreadsheet_id"`
+	Range         string          `json:"range"`
+	FetchedAt     time.Time       `json:"fetched_at"`
+	ResyncedAt    time.Time       `json:"resynced_at"`
+	Values        [][]interface{} `json:"values"`
+}
+
+// Games implements analyzer.GameSource, dropping any warnings.
+func (c *CachedSource) Games(ctx context.Context) ([]Game, error) {
+	games, _, err := c.GamesWithWarnings(ctx)
+	return games, err
+}
+
+// GamesWithWarnings implements analyzer.WarningSource. A failed fetch is
+// reported as a warning when cached rows are available.
+func (c *CachedSource) GamesWithWarnings(ctx context.Context) ([]Game, []analyzer.Warning, error) {
+	id, rng, cols, err := c.layout()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cache == nil {
+		c.cache = c.load(id, rng)
+	}
+
+	var warnings []analyzer.Warning
+	c.status = CacheStatus{FetchedAt: c.cache.FetchedAt, Cached: true}
+	if !c.Offline {
+		if err := c.refresh(ctx, id, rng); err != nil {
+			if len(c.cache.Values) == 0 {
+				return nil, nil, err
+			}
+			c.status.Error = err.Error()
+			warnings = append(warnings, analyzer.Warning{Message: fmt.Sprintf("%v; using cached rows from %s", err, c.cache.FetchedAt.Format(time.RFC3339))})
+		} else {
+			c.status = CacheStatus{FetchedAt: c.cache.FetchedAt}
+		}
+	}
+	if len(c.cache.Values) == 0 {
+		return nil, nil, fmt.Errorf("no game data found")
+	}
+	games, parsed := parseGameData(c.cache.Values, cols, c.Dates, c.Strict)
+	return games, append(warnings, parsed...), nil
+}
+
+// Status reports where the data from the most recent load came from.
+func (c *CachedSource) Status() CacheStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.status
+}
+
+// refresh brings the cache up to date, fetching only new rows unless a full
+// resync is due or the cached rows no longer line up with the sheet, and
+// saves it.
+func (c *CachedSource) refresh(ctx context.Context, id, rng string) error {
+	resync := c.Resync
+	if resync == 0 {
+		resync = DefaultResync
+	}
+	now := time.Now()
+	n := len(c.cache.Values)
+	tail, incremental := tailRange(rng, n-1)
+	if n == 0 || now.Sub(c.cache.ResyncedAt) >= resync {
+		incremental = false
+	}
+
+	if incremental {
+		rows, err := c.fetch(ctx, id, tail)
+		if err != nil {
+			return err
+		}
+		if len(rows) > 0 && sameRow(rows[0], c.cache.Values[n-1]) {
+			c.cache.Values = append(c.cache.Values, rows[1:]...)
+		} else {
+			incremental = false
+		}
+	}
+	if !incremental {
+		rows, err := c.fetch(ctx, id, rng)
+		if err != nil {
+			return err
+		}
+		c.cache.Values = rows
+		c.cache.ResyncedAt = now
+	}
+	c.cache.FetchedAt = now
+	return c.save()
+}
+
+// load reads the cache file, starting empty when it is missing, unreadable or
+// was written for a different sheet.
+func (c *CachedSource) load(id, rng string) *sheetCache {
+	empty := &sheetCache{SpreadsheetID: id, Range: rng}
+	data, err := os.ReadFile(c.Path)
+	if err != nil {
+		return empty
+	}
+	var cache sheetCache
+	if err := json.Unmarshal(data, &cache); err != nil || cache.SpreadsheetID != id || cache.Range != rng {
+		return empty
+	}
+	return &cache
+}
+
+// save writes the cache file atomically.
+func (c *CachedSource) save() error {
+	data, err := json.Marshal(c.cache)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(c.Path), ".sheet-cache-*")
+	if err != nil {
+		return fmt.Errorf("failed to write sheet cache: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write sheet cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write sheet cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.Path); err != nil {
+		return fmt.Errorf("failed to write sheet cache: %w", err)
+	}
+	return nil
+}
+
+// sameRow reports whether two rows of cell values read the same.
+func sameRow(a, b []interface{}) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if fmt.Sprint(a[i]) != fmt.Sprint(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// tailRange returns the part of an open-ended A1 range such as
+// "Ranked game log!A:N" that starts n rows below its first row, or false when
+// rng can't be narrowed (it ends at a fixed row or doesn't name columns).
+func tailRange(rng string, n int) (string, bool) {
+	sheet, cells := "", rng
+	if i := strings.LastIndex(rng, "!"); i >= 0 {
+		sheet, cells = rng[:i+1], rng[i+1:]
+	}
+	start, end, ok := strings.Cut(cells, ":")
+	if !ok {
+		return "", false
+	}
+	startCol, startRow := splitCell(start)
+	endCol, endRow := splitCell(end)
+	if startCol == "" || endCol == "" || endRow != "" {
+		return "", false
+	}
+	first := 1
+	if startRow != "" {
+		var err error
+		if first, err = strconv.Atoi(startRow); err != nil {
+			return "", false
+		}
+	}
+	return fmt.Sprintf("%s%s%d:%s", sheet, startCol, first+n, endCol), true
+}
+
+// splitCell splits an A1 cell reference such as "B2" into its column letters and row digits.
+func splitCell(ref string) (string, string) {
+	i := 0
+	for i < len(ref) && ((ref[i] >= 'A' && ref[i] <= 'Z') || (ref[i] >= 'a' && ref[i] <= 'z')) {
+		i++
+	}
+	return ref[:i], ref[i:]
+}
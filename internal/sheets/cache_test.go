@@ -0,0 +1,86 @@
+package sheets
+
+import (
+	"context"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestCachedSourceIncrementalAndOffline(t *testing.T) {
+	fake := &fakeValues{rows: [][]interface{}{
+		{"ID", "Timestamp", "Zap", "Draw", "", "1st", "2nd"},
+		{"1", "1/20/2020", "", "", "", "Marshall", "Dylan"},
+	}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "cache.json")
+	src := Source{SpreadsheetID: "test", Endpoint: srv.URL, HTTPClient: srv.Client()}
+	cached := &CachedSource{Source: src, Path: path}
+	ctx := context.Background()
+
+	if games, err := cached.Games(ctx); err != nil || len(games) != 1 {
+		t.Fatalf("first load: %d games, %v", len(games), err)
+	}
+	fake.rows = append(fake.rows, []interface{}{"2", "1/21/2020", "", "", "", "Dylan", "Colton"})
+	games, err := cached.Games(ctx)
+	if err != nil || len(games) != 2 || games[1].ID != "2" {
+		t.Fatalf("second load: %v, %v", games, err)
+	}
+	if len(fake.ranges) != 2 || fake.ranges[0] != "Ranked game log!A:N" || fake.ranges[1] != "Ranked game log!A2:N" {
+		t.Fatalf("expected a full fetch then rows from the last cached one on, got %q", fake.ranges)
+	}
+
+	// deleting a cached row shifts the last one up, so everything is refetched
+	fake.rows = slices.Delete(fake.rows, 1, 2)
+	fake.ranges = nil
+	games, err = cached.Games(ctx)
+	if err != nil || len(games) != 1 || games[0].ID != "2" {
+		t.Fatalf("load after deleting a row: %v, %v", games, err)
+	}
+	if len(fake.ranges) != 2 || fake.ranges[0] != "Ranked game log!A3:N" || fake.ranges[1] != "Ranked game log!A:N" {
+		t.Fatalf("expected the tail then a full fetch, got %q", fake.ranges)
+	}
+	fake.rows = append(fake.rows, []interface{}{"1", "1/20/2020", "", "", "", "Marshall", "Dylan"})
+	if games, err := cached.Games(ctx); err != nil || len(games) != 2 {
+		t.Fatalf("load after re-adding the row: %d games, %v", len(games), err)
+	}
+
+	// a new process with the sheet unreachable falls back to the cache file
+	fake.down = true
+	restarted := &CachedSource{Source: src, Path: path}
+	games, warnings, err := restarted.GamesWithWarnings(ctx)
+	if err != nil || len(games) != 2 || len(warnings) != 1 {
+		t.Fatalf("unreachable sheet: %d games, %v, %v", len(games), warnings, err)
+	}
+	if st := restarted.Status(); !st.Cached || st.Error == "" || st.FetchedAt.IsZero() {
+		t.Fatalf("expected a cached status with the fetch error, got %+v", st)
+	}
+
+	offline := &CachedSource{Source: src, Path: path, Offline: true}
+	games, warnings, err = offline.GamesWithWarnings(ctx)
+	if err != nil || len(games) != 2 || len(warnings) != 0 || !offline.Status().Cached {
+		t.Fatalf("offline: %d games, %v, %v", len(games), warnings, err)
+	}
+}
+
+func TestTailRange(t *testing.T) {
+	for _, tc := range []struct {
+		rng  string
+		n    int
+		want string
+		ok   bool
+	}{
+		{"Ranked game log!A:N", 5, "Ranked game log!A6:N", true},
+		{"Log!B2:AA", 3, "Log!B5:AA", true},
+		{"Log!A1:N100", 3, "", false},
+		{"Log", 3, "", false},
+	} {
+		got, ok := tailRange(tc.rng, tc.n)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("tailRange(%q, %d) = %q, %v; want %q, %v", tc.rng, tc.n, got, ok, tc.want, tc.ok)
+		}
+	}
+}
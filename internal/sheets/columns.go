@@ -0,0 +1,126 @@
+package sheets
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ColumnMap says which sheet column holds each game field. Columns are
+// zero-based indexes into the rows returned for the range; -1 marks a field
+// the sheet doesn't have.
+type ColumnMap struct {
+	ID           int
+	Date         int
+	TableZap     int
+	DrawGame     int
+	FirstPlayer  int // players in finishing order fill FirstPlayer..LastPlayer
+	LastPlayer   int
+	Turns        int
+	TurnOrder    int
+	Eliminations int
+}
+
+// DefaultColumnSpec is the layout of the original "Ranked game log" sheet.
+const DefaultColumnSpec = "id=A,date=B,table_zap=C,draw_game=D,players=F:K,turns=L,turn_order=M,eliminations=N"
+
+// ParseColumnMap parses a comma separated list of field=column assignments
+// such as DefaultColumnSpec. Columns are sheet letters; players takes a
+// letter range. Letters are relative to start, the first column of the range
+// being read (0 for a range starting at A). date and players are required.
+func ParseColumnMap(spec string, start int) (ColumnMap, error) {
+	m := ColumnMap{ID: -1, Date: -1, TableZap: -1, DrawGame: -1, FirstPlayer: -1, LastPlayer: -1, Turns: -1, TurnOrder: -1, Eliminations: -1}
+	fields := map[string]*int{
+		"id":           &m.ID,
+		"date":         &m.Date,
+		"table_zap":    &m.TableZap,
+		"draw_game":    &m.DrawGame,
+		"turns":        &m.Turns,
+		"turn_order":   &m.TurnOrder,
+		"eliminations": &m.Eliminations,
+	}
+	col := func(letters string) (int, error) {
+		c, err := columnIndex(letters)
+		if err != nil {
+			return 0, err
+		}
+		if c < start {
+			return 0, fmt.Errorf("column %s is before the start of the range", letters)
+		}
+		return c - start, nil
+	}
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(part, "=")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if !ok {
+			return m, fmt.Errorf("invalid column mapping %q: want field=column", part)
+		}
+		if name == "players" {
+			first, last, ok := strings.Cut(value, ":")
+			if !ok {
+				return m, fmt.Errorf("invalid players mapping %q: want a range like F:K", value)
+			}
+			var err error
+			if m.FirstPlayer, err = col(first); err != nil {
+				return m, err
+			}
+			if m.LastPlayer, err = col(last); err != nil {
+				return m, err
+			}
+			if m.LastPlayer < m.FirstPlayer {
+				return m, fmt.Errorf("invalid players mapping %q: range is reversed", value)
+			}
+			continue
+		}
+		field, ok := fields[name]
+		if !ok {
+			return m, fmt.Errorf("unknown field %q in column mapping", name)
+		}
+		c, err := col(value)
+		if err != nil {
+			return m, err
+		}
+		*field = c
+	}
+	if m.Date < 0 || m.FirstPlayer < 0 {
+		return m, fmt.Errorf("column mapping must include date and players")
+	}
+	return m, nil
+}
+
+// columnIndex converts sheet column letters (A, B, ..., Z, AA, ...) to a zero-based index.
+func columnIndex(letters string) (int, error) {
+	letters = strings.ToUpper(strings.TrimSpace(letters))
+	if letters == "" {
+		return 0, fmt.Errorf("missing column letter")
+	}
+	n := 0
+	for _, r := range letters {
+		if r < 'A' || r > 'Z' {
+			return 0, fmt.Errorf("invalid column %q", letters)
+		}
+		n = n*26 + int(r-'A'+1)
+	}
+	return n - 1, nil
+}
+
+// RangeStart returns the index of the first column of an A1 range such as
+// "Ranked game log!A:N", or 0 when the range doesn't name a column.
+func RangeStart(rng string) int {
+	if i := strings.LastIndex(rng, "!"); i >= 0 {
+		rng = rng[i+1:]
+	}
+	end := 0
+	for end < len(rng) && ((rng[end] >= 'A' && rng[end] <= 'Z') || (rng[end] >= 'a' && rng[end] <= 'z')) {
+		end++
+	}
+	c, err := columnIndex(rng[:end])
+	if err != nil {
+		return 0
+	}
+	return c
+}
@@ -0,0 +1,220 @@
+// Package sheets reads the game log from Google Sheets.
+package sheets
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/dylanlott/guildmaster/internal/analyzer"
+	"google.golang.org/api/option"
+	"google.golang.org/api/sheets/v4"
+)
+
+// Game is the shared game model.
+type Game = analyzer.Game
+
+// Defaults for the original "Ranked game log" sheet.
+const (
+	DefaultSpreadsheetID = "1-qr-ejHx07Hrr35OymMcGRH00-Jzb-k8S8-xS9P5vqk"
+	DefaultRange         = "Ranked game log!A:N"
+)
+
+// Source reads games from a Google Sheet with one game per row below a header row.
+type Source struct {
+	// APIKey authenticates read-only requests; when empty SCOREBOARD_API_KEY is used.
+	APIKey string
+	// CredentialsFile is a service account JSON key. When set it is used
+	// instead of the API key and allows games to be appended to the sheet.
+	CredentialsFile string
+	// Endpoint and HTTPClient override the Sheets API location and transport,
+	// e.g. to talk to a local fake. HTTPClient must add its own authorization.
+	Endpoint   string
+	HTTPClient *http.Client
+	// SpreadsheetID and Range locate the game log; empty uses the defaults.
+	SpreadsheetID string
+	Range         string
+	// Columns maps sheet columns to game fields; nil uses DefaultColumnSpec.
+	Columns *ColumnMap
+	// Dates parses the timestamp column.
+	Dates analyzer.DateParser
+	// Strict drops rows whose timestamp can't be parsed instead of keeping them.
+	Strict bool
+}
+
+// Games implements analyzer.GameSource, dropping any warnings.
+func (s Source) Games(ctx context.Context) ([]Game, error) {
+	games, _, err := s.GamesWithWarnings(ctx)
+	return games, err
+}
+
+// GamesWithWarnings implements analyzer.WarningSource, returning games in sheet order.
+func (s Source) GamesWithWarnings(ctx context.Context) ([]Game, []analyzer.Warning, error) {
+	id, readRange, cols, err := s.layout()
+	if err != nil {
+		return nil, nil, err
+	}
+	values, err := s.fetch(ctx, id, readRange)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(values) == 0 {
+		return nil, nil, fmt.Errorf("no game data found")
+	}
+	games, warnings := parseGameData(values, cols, s.Dates, s.Strict)
+	return games, warnings, nil
+}
+
+// fetch returns the raw cell values of rng.
+func (s Source) fetch(ctx context.Context, id, rng string) ([][]interface{}, error) {
+	srv, err := s.service(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := srv.Spreadsheets.Values.Get(id, rng).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve data from sheet: %w", err)
+	}
+	return resp.Values, nil
+}
+
+// service creates a Sheets client using the configured credentials.
+func (s Source) service(ctx context.Context) (*sheets.Service, error) {
+	var opts []option.ClientOption
+	switch {
+	case s.HTTPClient != nil:
+		opts = append(opts, option.WithHTTPClient(s.HTTPClient))
+	case s.CredentialsFile != "":
+		opts = append(opts, option.WithCredentialsFile(s.CredentialsFile), option.WithScopes(sheets.SpreadsheetsScope))
+	default:
+		key := s.APIKey
+		if key == "" {
+			key = os.Getenv("SCOREBOARD_API_KEY")
+		}
+		opts = append(opts, option.WithAPIKey(key))
+	}
+	if s.Endpoint != "" {
+		opts = append(opts, option.WithEndpoint(s.Endpoint))
+	}
+	srv, err := sheets.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sheets client: %w", err)
+	}
+	return srv, nil
+}
+
+// layout returns the spreadsheet ID, range and column mapping, applying defaults.
+func (s Source) layout() (string, string, ColumnMap, error) {
+	id, rng := s.SpreadsheetID, s.Range
+	if id == "" {
+		id = DefaultSpreadsheetID
+	}
+	if rng == "" {
+		rng = DefaultRange
+	}
+	if s.Columns != nil {
+		return id, rng, *s.Columns, nil
+	}
+	cols, err := ParseColumnMap(DefaultColumnSpec, RangeStart(rng))
+	return id, rng, cols, err
+}
+
+// parseGameData converts the raw Sheets values into a slice of Game. A row
+// whose timestamp can't be parsed is reported as a warning and is either
+// dropped (strict) or given the previous game's timestamp so it keeps its
+// place in the sheet when games are sorted chronologically.
+func parseGameData(values [][]interface{}, cols ColumnMap, dates analyzer.DateParser, strict bool) ([]Game, []analyzer.Warning) {
+	var games []Game
+	var warnings []analyzer.Warning
+	for idx, row := range values {
+		if idx == 0 {
+			// header row
+			continue
+		}
+		cell := func(i int) string {
+			if i < 0 || i >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(fmt.Sprintf("%v", row[i]))
+		}
+		gameID := cell(cols.ID)
+		date := cell(cols.Date)
+
+		g := Game{
+			ID:       gameID,
+			Date:     date,
+			TableZap: cell(cols.TableZap),
+			DrawGame: cell(cols.DrawGame),
+			Line:     idx + 1,
+		}
+		if g.ID == "" {
+			g.ID, g.LineID = strconv.Itoa(idx+1), true
+		}
+
+		team := false
+		for i := cols.FirstPlayer; i <= cols.LastPlayer; i++ {
+			name := cell(i)
+			if name == "" {
+				continue
+			}
+			// detect two-headed giant / team markers
+			if strings.Contains(name, "/") {
+				team = true
+				break
+			}
+			g.Rankings = append(g.Rankings, name)
+		}
+		if team {
+			// skip two-headed giant games for now
+			warnings = append(warnings, analyzer.Warning{Line: idx + 1, GameID: g.ID, Message: "team game skipped"})
+			continue
+		}
+		if len(g.Rankings) == 0 {
+			continue
+		}
+		for _, p := range parseGameMeta(&g, cell, cols) {
+			warnings = append(warnings, analyzer.Warning{Line: idx + 1, GameID: g.ID, Message: p})
+		}
+
+		ts, err := dates.Parse(date)
+		if err != nil {
+			if strict {
+				warnings = append(warnings, analyzer.Warning{Line: idx + 1, GameID: g.ID, Message: err.Error() + "; game skipped"})
+				continue
+			}
+			warnings = append(warnings, analyzer.Warning{Line: idx + 1, GameID: g.ID, Message: err.Error()})
+			if len(games) > 0 {
+				ts = games[len(games)-1].Timestamp
+			}
+		}
+		g.Timestamp = ts
+		games = append(games, g)
+	}
+	return games, warnings
+}
+
+// parseGameMeta fills the optional metadata columns: turn count, turn order
+// and eliminations. Metadata is optional, so problems with a malformed cell
+// are returned as messages rather than dropping the game, like the CSV schema
+// does.
+func parseGameMeta(g *Game, cell func(int) string, cols ColumnMap) []string {
+	var problems []string
+	turns, err := analyzer.ParseTurns(cell(cols.Turns))
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+	g.Turns = turns
+	g.TurnOrder = analyzer.ParseTurnOrder(cell(cols.TurnOrder))
+	if err := analyzer.CheckTurnOrder(*g); err != nil {
+		problems = append(problems, err.Error())
+	}
+	elims, err := analyzer.ParseEliminations(cell(cols.Eliminations))
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+	g.Eliminations = elims
+	return problems
+}
@@ -0,0 +1,80 @@
+package sheets
+
+import (
+	"testing"
+
+	"github.com/dylanlott/guildmaster/internal/analyzer"
+)
+
+func TestParseGameDataTimestamps(t *testing.T) {
+	values := [][]interface{}{
+		{"ID", "Timestamp", "Zap", "Draw", "", "1st", "2nd"},
+		{"1", "1/20/2020 19:30:00", "FALSE", "FALSE", "", "Marshall", "Dylan"},
+		{"2", "not a date", "FALSE", "FALSE", "", "Dylan", "Marshall"},
+		{"3", "2020-01-21", "FALSE", "FALSE", "", "Colton", "Dylan"},
+	}
+
+	cols, err := ParseColumnMap(DefaultColumnSpec, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	games, warnings := parseGameData(values, cols, analyzer.DateParser{}, false)
+	if len(games) != 3 || len(warnings) != 1 || warnings[0].GameID != "2" {
+		t.Fatalf("expected 3 games and a warning for game 2, got %d games, %v", len(games), warnings)
+	}
+	if !games[1].Timestamp.Equal(games[0].Timestamp) {
+		t.Fatalf("unparseable row should keep its place after the previous game, got %v", games[1].Timestamp)
+	}
+
+	games, warnings = parseGameData(values, cols, analyzer.DateParser{}, true)
+	if len(games) != 2 || len(warnings) != 1 {
+		t.Fatalf("strict mode should drop the bad row, got %d games, %v", len(games), warnings)
+	}
+}
+
+func TestParseGameDataMetadata(t *testing.T) {
+	values := [][]interface{}{
+		{"ID", "Timestamp", "Zap", "Draw", "", "1st", "2nd", "3rd", "", "", "", "Turns", "Turn order", "Eliminations"},
+		{"1", "1/20/2020", "", "", "", "Marshall", "Dylan", "Colton", "", "", "", "9", "Dylan, Colton, Marshall", "Marshall>Dylan"},
+		{"2", "1/21/2020", "", "", "", "Dylan", "Colton", "", "", "", "", "nine", "Dylan, Sara", "Dylan killed Colton"},
+	}
+	cols, err := ParseColumnMap(DefaultColumnSpec, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	games, warnings := parseGameData(values, cols, analyzer.DateParser{}, false)
+	if len(games) != 2 {
+		t.Fatalf("a malformed metadata cell dropped the game: got %d games", len(games))
+	}
+	if g := games[0]; g.Turns != 9 || len(g.TurnOrder) != 3 || len(g.Eliminations) != 1 {
+		t.Fatalf("metadata not parsed: %+v", g)
+	}
+	var got []string
+	for _, w := range warnings {
+		if w.GameID != "2" || w.Line != 3 {
+			t.Errorf("unexpected warning %+v", w)
+		}
+		got = append(got, w.Message)
+	}
+	if len(got) != 3 {
+		t.Fatalf("expected warnings for the turn count, turn order and eliminations of game 2, got %q", got)
+	}
+}
+
+func TestParseColumnMap(t *testing.T) {
+	cols, err := ParseColumnMap("date=C, players=D:G, turns=AA", RangeStart("Log!B2:AA"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := ColumnMap{ID: -1, Date: 1, TableZap: -1, DrawGame: -1, FirstPlayer: 2, LastPlayer: 5, Turns: 25, TurnOrder: -1, Eliminations: -1}
+	if cols != want {
+		t.Fatalf("expected %+v, got %+v", want, cols)
+	}
+
+	for _, spec := range []string{"players=F:K", "date=B,players=K:F", "date=B,players=F:K,winner=E", "date=A,players=B:C"} {
+		// the last spec is only invalid because column A is before a range starting at B
+		if _, err := ParseColumnMap(spec, 1); err == nil {
+			t.Errorf("expected error for %q", spec)
+		}
+	}
+}
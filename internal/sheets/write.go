@@ -0,0 +1,83 @@
+package sheets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dylanlott/guildmaster/internal/analyzer"
+	"google.golang.org/api/sheets/v4"
+)
+
+// DateLayout is used to write a game's timestamp when it has no Date text.
+const DateLayout = "1/2/2006 15:04:05"
+
+// AppendGame implements analyzer.GameWriter by appending g as a new row in
+// the same column layout the source reads. Writing requires a service
+// account (CredentialsFile); API keys are read-only.
+func (s Source) AppendGame(ctx context.Context, g Game) error {
+	if s.CredentialsFile == "" && s.HTTPClient == nil {
+		return errors.New("writing to Google Sheets requires a service account credentials file")
+	}
+	id, rng, cols, err := s.layout()
+	if err != nil {
+		return err
+	}
+	row, err := formatRow(g, cols)
+	if err != nil {
+		return err
+	}
+	srv, err := s.service(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = srv.Spreadsheets.Values.Append(id, rng, &sheets.ValueRange{Values: [][]interface{}{row}}).
+		ValueInputOption("RAW").
+		InsertDataOption("INSERT_ROWS").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return fmt.Errorf("unable to append game to sheet: %w", err)
+	}
+	return nil
+}
+
+// formatRow lays g out as a sheet row according to cols; it is the inverse of parseGameData.
+func formatRow(g Game, cols ColumnMap) ([]interface{}, error) {
+	if len(g.Rankings) < 2 {
+		return nil, fmt.Errorf("invalid game: need at least 2 players, got %d", len(g.Rankings))
+	}
+	if n := cols.LastPlayer - cols.FirstPlayer + 1; len(g.Rankings) > n {
+		return nil, fmt.Errorf("game has %d players but the sheet only has %d player columns", len(g.Rankings), n)
+	}
+	date := g.Date
+	if date == "" && !g.Timestamp.IsZero() {
+		date = g.Timestamp.Format(DateLayout)
+	}
+	turns := ""
+	if g.Turns > 0 {
+		turns = fmt.Sprint(g.Turns)
+	}
+
+	width := max(cols.ID, cols.Date, cols.TableZap, cols.DrawGame, cols.LastPlayer, cols.Turns, cols.TurnOrder, cols.Eliminations) + 1
+	row := make([]interface{}, width)
+	for i := range row {
+		row[i] = ""
+	}
+	set := func(col int, v string) {
+		if col >= 0 {
+			row[col] = v
+		}
+	}
+	set(cols.ID, g.ID)
+	set(cols.Date, date)
+	set(cols.TableZap, g.TableZap)
+	set(cols.DrawGame, g.DrawGame)
+	for i, name := range g.Rankings {
+		set(cols.FirstPlayer+i, name)
+	}
+	set(cols.Turns, turns)
+	set(cols.TurnOrder, analyzer.FormatTurnOrder(g.TurnOrder))
+	set(cols.Eliminations, analyzer.FormatEliminations(g.Eliminations))
+	return row, nil
+}
@@ -0,0 +1,114 @@
+package sheets
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dylanlott/guildmaster/internal/analyzer"
+)
+
+// fakeValues is a minimal in-memory stand-in for the Sheets values API.
+// It assumes the range starts at row 1 unless the request names a row.
+type fakeValues struct {
+	mu     sync.Mutex
+	rows   [][]interface{}
+	down   bool     // fail every request
+	ranges []string // ranges requested with GET
+}
+
+func (f *fakeValues) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.down {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	switch {
+	case r.Method == http.MethodGet && strings.Contains(r.URL.Path, "/values/"):
+		_, rng, _ := strings.Cut(r.URL.Path, "/values/")
+		f.ranges = append(f.ranges, rng)
+		rows := f.rows
+		if i := strings.LastIndex(rng, "!"); i >= 0 {
+			rng = rng[i+1:]
+		}
+		start, _, _ := strings.Cut(rng, ":")
+		if _, row := splitCell(start); row != "" {
+			n, _ := strconv.Atoi(row)
+			rows = rows[min(n-1, len(rows)):]
+		}
+		json.NewEncoder(w).Encode(map[string]any{"values": rows})
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, ":append"):
+		if r.URL.Query().Get("valueInputOption") == "" {
+			http.Error(w, "missing valueInputOption", http.StatusBadRequest)
+			return
+		}
+		var body struct {
+			Values [][]interface{} `json:"values"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.rows = append(f.rows, body.Values...)
+		json.NewEncoder(w).Encode(map[string]any{})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestAppendGameRoundTrip(t *testing.T) {
+	fake := &fakeValues{rows: [][]interface{}{
+		{"ID", "Timestamp", "Zap", "Draw", "", "1st", "2nd", "3rd", "4th", "5th", "6th", "Turns", "Turn order", "Eliminations"},
+	}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	src := Source{SpreadsheetID: "test", Endpoint: srv.URL, HTTPClient: srv.Client()}
+	want := analyzer.Game{
+		ID:           "42",
+		Date:         "3/4/2024 20:00:00",
+		Rankings:     []string{"Marshall", "Dylan", "Colton"},
+		Turns:        9,
+		TurnOrder:    []string{"Dylan", "Colton", "Marshall"},
+		Eliminations: []analyzer.Elimination{{Killer: "Marshall", Victim: "Colton"}},
+	}
+	ctx := context.Background()
+	if err := src.AppendGame(ctx, want); err != nil {
+		t.Fatalf("AppendGame: %v", err)
+	}
+
+	games, warnings, err := src.GamesWithWarnings(ctx)
+	if err != nil {
+		t.Fatalf("GamesWithWarnings: %v", err)
+	}
+	if len(games) != 1 || len(warnings) != 0 {
+		t.Fatalf("expected 1 game and no warnings, got %d games, %v", len(games), warnings)
+	}
+	got := games[0]
+	if got.ID != want.ID || got.Date != want.Date || got.Turns != want.Turns ||
+		strings.Join(got.Rankings, ",") != strings.Join(want.Rankings, ",") ||
+		analyzer.FormatTurnOrder(got.TurnOrder) != analyzer.FormatTurnOrder(want.TurnOrder) ||
+		analyzer.FormatEliminations(got.Eliminations) != analyzer.FormatEliminations(want.Eliminations) {
+		t.Fatalf("round trip mismatch:\n got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestAppendGameRequiresCredentials(t *testing.T) {
+	err := Source{APIKey: "key"}.AppendGame(context.Background(), analyzer.Game{Rankings: []string{"A", "B"}})
+	if err == nil {
+		t.Fatal("expected an error writing with only an API key")
+	}
+}
+
+func TestFormatRowTooManyPlayers(t *testing.T) {
+	cols, _ := ParseColumnMap("date=A,players=B:C", 0)
+	if _, err := formatRow(analyzer.Game{Rankings: []string{"A", "B", "C"}}, cols); err == nil {
+		t.Fatal("expected an error for more players than columns")
+	}
+}
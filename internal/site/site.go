@@ -0,0 +1,191 @@
+// Package site renders the league as a static website: the landing page,
+// a page per player with their rating history and the full game archive.
+package site
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/dylanlott/guildmaster/internal/analyzer"
+	"github.com/dylanlott/guildmaster/internal/export"
+	"github.com/dylanlott/guildmaster/internal/server"
+)
+
+//go:embed *.tmpl
+var tmplFS embed.FS
+
+var templates = template.Must(template.New("").Funcs(server.TemplateFuncs).ParseFS(tmplFS, "*.tmpl"))
+
+// playerPage is the data rendered by player.tmpl.
+type playerPage struct {
+	Name       string
+	Rating     int
+	Rank       int
+	Games      int
+	Wins       int
+	Chart      chart
+	HeadToHead []analyzer.HeadToHeadStat
+	Recent     []analyzer.Game // most recent first
+}
+
+// archivePage is the data rendered by games.tmpl.
+type archivePage struct {
+	Games []analyzer.Game // most recent first
+}
+
+// Build writes the site for games, in chronological order, into dir:
+// index.html, games.html, players/<slug>.html and CSV exports of the
+// rankings and history. Existing files with the same names are replaced.
+func Build(dir string, games []analyzer.Game) error {
+	elo := analyzer.InitializeElo()
+	scores, err := analyzer.RateGames(elo, games, nil)
+	if err != nil {
+		return err
+	}
+	history, err := analyzer.RatingHistory(elo, games, nil)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "players"), 0o755); err != nil {
+		return err
+	}
+
+	names := slices.Collect(maps.Keys(scores))
+	for _, g := range games {
+		names = append(names, g.Rankings...)
+	}
+	slugs := playerSlugs(names)
+	tmpl := template.Must(templates.Clone()).Funcs(template.FuncMap{
+		"slug": func(name string) string { return slugs[name] },
+	})
+
+	landing := server.NewLandingPage(games, scores)
+	landing.Static = true
+	landing.PlayerPages = slugs
+	if err := writeFile(filepath.Join(dir, "index.html"), func(w io.Writer) error {
+		return server.RenderLanding(w, landing)
+	}); err != nil {
+		return err
+	}
+
+	newestFirst := slices.Clone(games)
+	slices.Reverse(newestFirst)
+	if err := render(tmpl, filepath.Join(dir, "games.html"), "games.tmpl", archivePage{Games: newestFirst}); err != nil {
+		return err
+	}
+
+	h2h := analyzer.HeadToHead(games)
+	for i, row := range landing.Ranked {
+		p := playerPage{Name: row.Name, Rating: row.Score, Rank: i + 1}
+		for _, g := range newestFirst {
+			if !slices.Contains(g.Rankings, row.Name) {
+				continue
+			}
+			p.Games++
+			if g.Rankings[0] == row.Name {
+				p.Wins++
+			}
+			if len(p.Recent) < 20 {
+				p.Recent = append(p.Recent, g)
+			}
+		}
+		for _, st := range h2h {
+			if st.Player == row.Name {
+				p.HeadToHead = append(p.HeadToHead, st)
+			}
+		}
+		p.Chart = newChart(history[row.Name])
+		if err := render(tmpl, filepath.Join(dir, "players", slugs[row.Name]+".html"), "player.tmpl", p); err != nil {
+			return err
+		}
+	}
+
+	for name, t := range map[string]export.Table{"rankings.csv": export.Rankings(scores), "games.csv": export.History(games)} {
+		if err := writeFile(filepath.Join(dir, name), func(w io.Writer) error {
+			return export.Write(w, t, export.CSV)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func render(t *template.Template, path, name string, data any) error {
+	return writeFile(path, func(w io.Writer) error {
+		return t.ExecuteTemplate(w, name, data)
+	})
+}
+
+// playerSlugs names each player's page. Names that make the same slug, or
+// none at all, are told apart by a numeric suffix, in name order so the
+// pages keep their names between builds.
+func playerSlugs(names []string) map[string]string {
+	slugs := make(map[string]string, len(names))
+	taken := make(map[string]bool, len(names))
+	for _, name := range slices.Compact(slices.Sorted(slices.Values(names))) {
+		base := server.Slug(name)
+		if base == "" {
+			base = "player"
+		}
+		slug := base
+		for n := 2; taken[slug]; n++ {
+			slug = fmt.Sprintf("%s-%d", base, n)
+		}
+		taken[slug] = true
+		slugs[name] = slug
+	}
+	return slugs
+}
+
+// writeFile renders into memory first so a failed render leaves no partial file.
+func writeFile(path string, fn func(io.Writer) error) error {
+	var buf bytes.Buffer
+	if err := fn(&buf); err != nil {
+		return fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// chart is an SVG line chart of a rating history.
+type chart struct {
+	Width, Height int
+	Points        string // polyline points
+	Min, Max      int
+	Baseline      int // y of the starting rating
+	First, Last   string
+}
+
+// newChart scales points into a fixed size chart.
+func newChart(points []analyzer.RatingPoint) chart {
+	const width, height, pad = 640, 200, 10
+	c := chart{Width: width, Height: height}
+	if len(points) == 0 {
+		return c
+	}
+	c.Min, c.Max = analyzer.DefaultStartingScore, analyzer.DefaultStartingScore
+	for _, p := range points {
+		c.Min, c.Max = min(c.Min, p.Rating), max(c.Max, p.Rating)
+	}
+	span := float64(max(c.Max-c.Min, 1))
+	y := func(r int) int {
+		return pad + int(float64(c.Max-r)/span*float64(height-2*pad))
+	}
+	// the chart starts at the starting rating before the first game
+	coords := []string{fmt.Sprintf("%d,%d", pad, y(analyzer.DefaultStartingScore))}
+	for i, p := range points {
+		x := pad + (i+1)*(width-2*pad)/len(points)
+		coords = append(coords, fmt.Sprintf("%d,%d", x, y(p.Rating)))
+	}
+	c.Points = strings.Join(coords, " ")
+	c.Baseline = y(analyzer.DefaultStartingScore)
+	c.First, c.Last = points[0].Date, points[len(points)-1].Date
+	return c
+}
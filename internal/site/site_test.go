@@ -0,0 +1,68 @@
+package site
+
+import (
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dylanlott/guildmaster/internal/analyzer"
+)
+
+func TestBuild(t *testing.T) {
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	games := []analyzer.Game{
+		{ID: "1", Date: "1/1/2024", Timestamp: day, Rankings: []string{"Marshall", "Dylan/Sara"}},
+		{ID: "2", Date: "1/2/2024", Timestamp: day.AddDate(0, 0, 1), Rankings: []string{"Dylan/Sara", "Marshall"}, Notes: "<revenge>"},
+	}
+	dir := t.TempDir()
+	if err := Build(dir, games); err != nil {
+		t.Fatal(err)
+	}
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	if index := read("index.html"); !strings.Contains(index, `href="players/dylan-sara.html"`) || strings.Contains(index, "refreshBtn\"") {
+		t.Errorf("index should link players and leave out the refresh button:\n%s", index)
+	}
+	if archive := read("games.html"); !strings.Contains(archive, "&lt;revenge&gt;") {
+		t.Errorf("archive should list escaped notes:\n%s", archive)
+	}
+	player := read("players/marshall.html")
+	if !strings.Contains(player, "<polyline") || !strings.Contains(player, `href="dylan-sara.html"`) {
+		t.Errorf("player page should chart ratings and link opponents:\n%s", player)
+	}
+	if !strings.HasPrefix(read("rankings.csv"), "Rank,Player,Elo\n") {
+		t.Errorf("missing rankings export")
+	}
+}
+
+func TestBuildSlugCollisions(t *testing.T) {
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	games := []analyzer.Game{
+		{ID: "1", Date: "1/1/2024", Timestamp: day, Rankings: []string{"Dylan/Sara", "Dylan Sara", "???"}},
+	}
+	dir := t.TempDir()
+	if err := Build(dir, games); err != nil {
+		t.Fatal(err)
+	}
+	index, err := os.ReadFile(filepath.Join(dir, "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, slug := range map[string]string{"Dylan Sara": "dylan-sara", "Dylan/Sara": "dylan-sara-2", "???": "player"} {
+		page, err := os.ReadFile(filepath.Join(dir, "players", slug+".html"))
+		if err != nil || !strings.Contains(string(page), "<h1>"+template.HTMLEscapeString(name)) {
+			t.Errorf("players/%s.html should be %s's page: %v", slug, name, err)
+		}
+		if link := `href="players/` + slug + `.html">` + template.HTMLEscapeString(name) + "<"; !strings.Contains(string(index), link) {
+			t.Errorf("index should link %s as %s", name, link)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package source
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// League is one playgroup hosted by the server: its game log and how its
+// games are rated.
+type League struct {
+	// ID names the league in URLs, e.g. /api/leagues/commander/scores.
+	ID string `json:"id"`
+	// Name is shown on the landing page; it defaults to ID.
+	Name string `json:"name,omitempty"`
+	Config
+	// K and D configure the Elo rating; 0 uses the defaults of 40 and 800.
+	K int     `json:"k,omitempty"`
+	D float64 `json:"d,omitempty"`
+	// SeatAdvantage adds the estimated seat advantage to expected scores.
+	SeatAdvantage bool `json:"seat_advantage,omitempty"`
+	// Quorum is how many players must confirm a game recorded through the
+	// server; 0 means more than half of them.
+	Quorum int `json:"quorum,omitempty"`
+}
+
+var leagueID = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// LoadLeagues reads a JSON array of leagues, e.g.
+//
+//	[{"id": "commander", "name": "Commander pod", "source": "sheets", "sheet_cache": "commander.json"},
+//	 {"id": "cube", "source": "jsonl", "path": "cube.jsonl", "k": 32},
+//	 {"id": "ladder", "name": "1v1 ladder", "source": "csv", "path": "ladder.csv", "d": 400}]
+//
+// IDs must be unique lowercase letters, digits, dashes and underscores.
+func LoadLeagues(path string) ([]League, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read leagues: %w", err)
+	}
+	var leagues []League
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&leagues); err != nil {
+		return nil, fmt.Errorf("invalid leagues file %s: %w", path, err)
+	}
+	if len(leagues) == 0 {
+		return nil, fmt.Errorf("leagues file %s lists no leagues", path)
+	}
+	seen := make(map[string]bool)
+	for i := range leagues {
+		l := &leagues[i]
+		if !leagueID.MatchString(l.ID) {
+			return nil, fmt.Errorf("league %d: invalid id %q: want lowercase letters, digits, - or _", i+1, l.ID)
+		}
+		if seen[l.ID] {
+			return nil, fmt.Errorf("league %s is listed more than once", l.ID)
+		}
+		seen[l.ID] = true
+		if l.Name == "" {
+			l.Name = l.ID
+		}
+		if l.Kind == "" {
+			return nil, fmt.Errorf("league %s: missing source: want %s", l.ID, Kinds)
+		}
+		if l.K < 0 || l.D < 0 || l.Quorum < 0 {
+			return nil, fmt.Errorf("league %s: k, d and quorum can't be negative", l.ID)
+		}
+	}
+	return leagues, nil
+}
@@ -0,0 +1,56 @@
+package source
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadLeagues(t *testing.T) {
+	load := func(data string) ([]League, error) {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "leagues.json")
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return LoadLeagues(path)
+	}
+
+	leagues, err := load(`[
+		{"id": "commander", "name": "Commander pod", "source": "csv", "path": "pod.csv", "quorum": 3},
+		{"id": "1v1_ladder", "source": "jsonl", "path": "ladder.jsonl", "k": 32, "d": 400}
+	]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leagues) != 2 {
+		t.Fatalf("got %d leagues, want 2", len(leagues))
+	}
+	if l := leagues[0]; l.Name != "Commander pod" || l.Kind != "csv" || l.Path != "pod.csv" || l.Quorum != 3 {
+		t.Errorf("first league = %+v", l)
+	}
+	if l := leagues[1]; l.Name != "1v1_ladder" || l.K != 32 || l.D != 400 {
+		t.Errorf("second league = %+v, want its name to default to its ID", l)
+	}
+
+	for data, want := range map[string]string{
+		`[{"id": "Commander", "source": "csv"}]`:                             `invalid id "Commander"`,
+		`[{"id": "", "source": "csv"}]`:                                      `invalid id ""`,
+		`[{"id": "-pod", "source": "csv"}]`:                                  `invalid id "-pod"`,
+		`[{"id": "pod/cube", "source": "csv"}]`:                              `invalid id "pod/cube"`,
+		`[{"id": "pod", "source": "csv"}, {"id": "pod", "source": "jsonl"}]`: "league pod is listed more than once",
+		`[{"id": "pod"}]`:                                                    "missing source",
+		`[{"id": "pod", "source": "csv", "k": -1}]`:                          "can't be negative",
+		`[{"id": "pod", "source": "csv", "rating": "elo"}]`:                  "unknown field",
+		`[]`:            "lists no leagues",
+		`{"id": "pod"}`: "invalid leagues file",
+	} {
+		if _, err := load(data); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: error %v, want %q", data, err, want)
+		}
+	}
+	if _, err := LoadLeagues(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("loaded a missing leagues file")
+	}
+}
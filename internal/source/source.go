@@ -0,0 +1,119 @@
+// Package source selects the game log a command reads from.
+package source
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dylanlott/guildmaster/internal/analyzer"
+	"github.com/dylanlott/guildmaster/internal/sheets"
+)
+
+// Kinds lists the supported values for a -source flag.
+const Kinds = "csv, jsonl or sheets"
+
+// Config describes which game log to read and how to parse it. In a leagues
+// file it is written as JSON with the flag names in snake_case.
+type Config struct {
+	// Kind is "csv", "jsonl" or "sheets".
+	Kind string `json:"source,omitempty"`
+	// Path is the file read by the csv and jsonl kinds.
+	Path string `json:"path,omitempty"`
+	// DateLayouts is a comma separated list of Go time layouts; empty uses analyzer.DateLayouts.
+	DateLayouts string `json:"date_layouts,omitempty"`
+	// TimeZone is the IANA zone of dates that don't name one; empty means UTC.
+	TimeZone string `json:"timezone,omitempty"`
+	// StrictDates drops games whose date can't be parsed.
+	StrictDates bool `json:"strict_dates,omitempty"`
+
+	// SheetID, SheetRange and SheetColumns locate the game log for the sheets
+	// kind; empty values use the sheets package defaults.
+	SheetID      string `json:"sheet_id,omitempty"`
+	SheetRange   string `json:"sheet_range,omitempty"`
+	SheetColumns string `json:"sheet_columns,omitempty"`
+	// SheetCredentials is a service account JSON key used to write to the sheet.
+	SheetCredentials string `json:"sheet_credentials,omitempty"`
+	// SheetCache is a file caching the sheet's rows between runs; empty disables caching.
+	SheetCache string `json:"sheet_cache,omitempty"`
+	// Offline reads the sheet from SheetCache without contacting Google.
+	Offline bool `json:"offline,omitempty"`
+}
+
+// RegisterFlags adds flags for every field to fs, using the current values as defaults.
+func (c *Config) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.Kind, "source", c.Kind, "game source: "+Kinds)
+	fs.StringVar(&c.Path, "path", c.Path, "game log file for the csv and jsonl sources")
+	fs.StringVar(&c.DateLayouts, "date-layouts", c.DateLayouts, "comma separated Go time layouts for game dates (default: common US, ISO and RFC formats)")
+	fs.StringVar(&c.TimeZone, "timezone", c.TimeZone, "time zone of game dates without one, e.g. America/Denver (default UTC)")
+	fs.BoolVar(&c.StrictDates, "strict-dates", c.StrictDates, "skip games whose date can't be parsed instead of keeping them in log order")
+	fs.StringVar(&c.SheetID, "sheet-id", envOr(c.SheetID, "SCOREBOARD_SHEET_ID", sheets.DefaultSpreadsheetID), "Google Sheets spreadsheet ID (env SCOREBOARD_SHEET_ID)")
+	fs.StringVar(&c.SheetRange, "sheet-range", envOr(c.SheetRange, "SCOREBOARD_SHEET_RANGE", sheets.DefaultRange), "A1 range holding the game log (env SCOREBOARD_SHEET_RANGE)")
+	fs.StringVar(&c.SheetColumns, "sheet-columns", envOr(c.SheetColumns, "SCOREBOARD_SHEET_COLUMNS", sheets.DefaultColumnSpec), "mapping of game fields to sheet columns (env SCOREBOARD_SHEET_COLUMNS)")
+	fs.StringVar(&c.SheetCredentials, "sheet-credentials", envOr(c.SheetCredentials, "SCOREBOARD_CREDENTIALS", ""), "service account JSON key for writing games to the sheet (env SCOREBOARD_CREDENTIALS)")
+	fs.StringVar(&c.SheetCache, "sheet-cache", envOr(c.SheetCache, "SCOREBOARD_SHEET_CACHE", ""), "file caching the sheet's rows; only new rows are fetched and the cache is used when Sheets is unreachable (env SCOREBOARD_SHEET_CACHE)")
+	fs.BoolVar(&c.Offline, "offline", c.Offline, "serve the sheet from -sheet-cache without contacting Google")
+}
+
+// envOr returns value if set, else the environment variable key, else def.
+func envOr(value, key, def string) string {
+	if value != "" {
+		return value
+	}
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// Open returns the configured game source.
+func (c Config) Open() (analyzer.GameSource, error) {
+	dates, err := c.DateParser()
+	if err != nil {
+		return nil, err
+	}
+	switch c.Kind {
+	case "csv":
+		return analyzer.CSVSource{Path: c.Path, Dates: dates, Strict: c.StrictDates}, nil
+	case "jsonl":
+		return analyzer.JSONLSource{Path: c.Path, Dates: dates, Strict: c.StrictDates}, nil
+	case "sheets":
+		src := sheets.Source{SpreadsheetID: c.SheetID, Range: c.SheetRange, CredentialsFile: c.SheetCredentials, Dates: dates, Strict: c.StrictDates}
+		if c.SheetColumns != "" {
+			cols, err := sheets.ParseColumnMap(c.SheetColumns, sheets.RangeStart(src.Range))
+			if err != nil {
+				return nil, fmt.Errorf("invalid sheet columns: %w", err)
+			}
+			src.Columns = &cols
+		}
+		if c.SheetCache != "" {
+			return &sheets.CachedSource{Source: src, Path: c.SheetCache, Offline: c.Offline}, nil
+		}
+		if c.Offline {
+			return nil, fmt.Errorf("-offline needs a -sheet-cache file")
+		}
+		return src, nil
+	default:
+		return nil, fmt.Errorf("unknown game source %q: want %s", c.Kind, Kinds)
+	}
+}
+
+// DateParser returns the parser for the configured date layouts and time zone.
+func (c Config) DateParser() (analyzer.DateParser, error) {
+	var p analyzer.DateParser
+	for _, layout := range strings.Split(c.DateLayouts, ",") {
+		if layout = strings.TrimSpace(layout); layout != "" {
+			p.Layouts = append(p.Layouts, layout)
+		}
+	}
+	if c.TimeZone != "" {
+		loc, err := time.LoadLocation(c.TimeZone)
+		if err != nil {
+			return p, fmt.Errorf("invalid time zone: %w", err)
+		}
+		p.Location = loc
+	}
+	return p, nil
+}
@@ -0,0 +1,62 @@
+// Package watch notices when a file changes on disk.
+package watch
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// DefaultInterval is how often Changes checks the file.
+const DefaultInterval = 500 * time.Millisecond
+
+// state identifies a version of a file.
+type state struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+func stat(path string) state {
+	info, err := os.Stat(path)
+	if err != nil {
+		return state{}
+	}
+	return state{exists: true, size: info.Size(), modTime: info.ModTime()}
+}
+
+// Changes polls path every interval and sends on the returned channel when
+// its size or modification time changes, including when it is replaced by an
+// editor saving through a temporary file. Changes that happen before the
+// receiver catches up are coalesced into one. The channel is closed when ctx
+// is done.
+func Changes(ctx context.Context, path string, interval time.Duration) <-chan struct{} {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	ch := make(chan struct{}, 1)
+	go func() {
+		defer close(ch)
+		last := stat(path)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			cur := stat(path)
+			// a file missing mid-save isn't a change worth reloading for
+			if cur == last || !cur.exists {
+				continue
+			}
+			last = cur
+			select {
+			case ch <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return ch
+}
@@ -0,0 +1,42 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "games.csv")
+	if err := os.WriteFile(path, []byte("a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := Changes(ctx, path, 10*time.Millisecond)
+
+	select {
+	case <-changes:
+		t.Fatal("unexpected change before the file was written")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// replace the file the way editors save, through a temporary file
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte("a\nb\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-changes:
+	case <-time.After(time.Second):
+		t.Fatal("change not reported")
+	}
+
+	cancel()
+	for range changes {
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dylanlott/guildmaster/internal/analyzer"
+	"github.com/dylanlott/guildmaster/internal/lint"
+	"github.com/dylanlott/guildmaster/internal/source"
+)
+
+// runLint checks the game log for data entry mistakes. It fails when any
+// error is found (or any warning with -strict) so it can gate CI.
+func runLint(args []string) error {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	src := source.Config{Kind: "csv", Path: "./mtgscores.csv"}
+	src.RegisterFlags(fs)
+	format := fs.String("format", "text", "output format: text or json")
+	roster := fs.String("players", "", "file listing known player names, one per line")
+	strict := fs.Bool("strict", false, "fail on warnings as well as errors")
+	fs.Parse(args)
+
+	var opts lint.Options
+	if *roster != "" {
+		data, err := os.ReadFile(*roster)
+		if err != nil {
+			return fmt.Errorf("failed to read roster: %w", err)
+		}
+		for _, name := range strings.Split(string(data), "\n") {
+			if name = strings.TrimSpace(name); name != "" {
+				opts.Players = append(opts.Players, name)
+			}
+		}
+	}
+
+	gs, err := src.Open()
+	if err != nil {
+		return err
+	}
+	games, warnings, err := analyzer.LoadGames(context.Background(), gs)
+	if err != nil {
+		return err
+	}
+	issues := lint.Check(games, warnings, opts)
+	counts := lint.Count(issues)
+
+	switch *format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		report := struct {
+			Games  int                   `json:"games"`
+			Issues []lint.Issue          `json:"issues"`
+			Counts map[lint.Severity]int `json:"counts"`
+		}{Games: len(games), Issues: issues, Counts: counts}
+		if report.Issues == nil {
+			report.Issues = []lint.Issue{}
+		}
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	case "text":
+		for _, i := range issues {
+			fmt.Println(i)
+		}
+		fmt.Printf("%d games checked: %d errors, %d warnings, %d info\n",
+			len(games), counts[lint.Error], counts[lint.Warning], counts[lint.Info])
+	default:
+		return fmt.Errorf("unknown format %q: want text or json", *format)
+	}
+
+	if counts[lint.Error] > 0 || (*strict && counts[lint.Warning] > 0) {
+		return fmt.Errorf("found %d errors and %d warnings", counts[lint.Error], counts[lint.Warning])
+	}
+	return nil
+}
@@ -1,43 +1,73 @@
 package main
 
 import (
-	"encoding/csv"
+	"context"
 	"flag"
 	"fmt"
 	"io"
 	"log"
-	"math"
 	"os"
 	"sort"
-	"strings"
 
-	elogo "github.com/kortemy/elo-go"
+	"github.com/dylanlott/guildmaster/internal/analyzer"
+	"github.com/dylanlott/guildmaster/internal/source"
+	"github.com/dylanlott/guildmaster/internal/watch"
 )
 
-// The default starting score of players in our analyzer.
-const defaultStartingScore = 1500
-
 type finalScore struct {
 	player   string
 	eloScore int
 }
 
+// subcommands maps the first command line argument to its handler. Without a
+// subcommand guildmaster prints the current rankings.
+var subcommands = map[string]func(args []string) error{
+	"project":   runProject,
+	"bootstrap": runBootstrap,
+	"lint":      runLint,
+	"games":     runGames,
+	"record":    runRecord,
+	"convert":   runConvert,
+	"export":    runExport,
+	"site":      runSite,
+	"edit":      runEdit,
+	"void":      runVoid,
+	"token":     runToken,
+	"confirm":   runConfirm,
+}
+
 func main() {
-	path := flag.String("path", "./mtgscores.csv", "path to analyze with tracker")
+	if len(os.Args) > 1 {
+		if cmd, ok := subcommands[os.Args[1]]; ok {
+			if err := cmd(os.Args[2:]); err != nil {
+				log.Fatalf("%s: %v", os.Args[1], err)
+			}
+			return
+		}
+	}
+
+	src := source.Config{Kind: "csv", Path: "./mtgscores.csv"}
+	src.RegisterFlags(flag.CommandLine)
 	useTUI := flag.Bool("tui", false, "use terminal UI for displaying rankings")
+	watchLog := flag.Bool("watch", false, "reload the rankings whenever the game log file changes")
 	flag.Parse()
 
+	if *watchLog && !*useTUI {
+		if err := watchRankings(src, os.Stdout); err != nil {
+			log.Fatalf("Error watching scores: %v", err)
+		}
+		return
+	}
+
 	// Suppress logs during TUI mode to prevent interference with display
 	if *useTUI {
 		log.SetOutput(io.Discard)
 	}
 
-	log.Printf("Analyzing scores for %s", *path)
+	log.Printf("Analyzing scores for %s", src.Path)
 
-	elo := initializeElo()
-	scores := make(map[string]int)
-
-	if err := processScores(*path, elo, scores); err != nil {
+	scores, err := loadScores(src)
+	if err != nil {
 		if *useTUI {
 			log.SetOutput(os.Stderr) // Restore for error display
 		}
@@ -48,7 +78,17 @@ func main() {
 
 	if *useTUI {
 		// Use the TUI to display rankings
-		if err := DisplayRankingsTUI(finalScores); err != nil {
+		var changes <-chan struct{}
+		if *watchLog {
+			path, err := watchedPath(src)
+			if err != nil {
+				log.SetOutput(os.Stderr)
+				log.Fatalf("Error watching scores: %v", err)
+			}
+			changes = watch.Changes(context.Background(), path, watch.DefaultInterval)
+		}
+		reload := func() ([]finalScore, error) { return reloadScores(src) }
+		if err := DisplayRankingsTUI(finalScores, reload, changes); err != nil {
 			log.SetOutput(os.Stderr) // Restore log output to show errors
 			log.Fatalf("Error in TUI: %v", err)
 		}
@@ -60,102 +100,31 @@ func main() {
 	}
 }
 
-func initializeElo() *elogo.Elo {
-	elo := elogo.NewElo()
-	elo.D = 800
-	elo.K = 40
-	return elo
-}
-
-func processScores(path string, elo *elogo.Elo, scores map[string]int) error {
-	file, err := os.Open(path)
+// loadGames reads every game from the chosen source, oldest first, logging
+// any per-game warnings the source reports.
+func loadGames(cfg source.Config) ([]analyzer.Game, error) {
+	src, err := cfg.Open()
 	if err != nil {
-		return fmt.Errorf("failed to open scores file: %w", err)
+		return nil, err
 	}
-	defer file.Close()
-
-	reader := csv.NewReader(file)
-	for {
-		record, err := reader.Read()
-		if err != nil {
-			if err == io.EOF {
-				break
-			}
-			return fmt.Errorf("error reading record: %w", err)
-		}
-
-		if len(record) < 3 {
-			continue
-		}
-
-		game := parseGame(record[2:])
-		if len(game) >= 2 {
-			if err := scoreGame(elo, scores, game); err != nil {
-				return fmt.Errorf("failed to score game: %w", err)
-			}
-		}
+	games, warnings, err := analyzer.LoadGames(context.Background(), src)
+	if err != nil {
+		return nil, err
 	}
-	return nil
-}
-
-func parseGame(players []string) []string {
-	game := make([]string, 0, len(players))
-	for _, player := range players {
-		player = strings.TrimSpace(player)
-		if player == "" {
-			break
-		}
-		game = append(game, player)
+	for _, w := range warnings {
+		log.Printf("warning: %s", w)
 	}
-	return game
+	analyzer.SortChronologically(games)
+	return games, nil
 }
 
-func scoreGame(elo *elogo.Elo, scores map[string]int, game []string) error {
-	numPlayers := len(game)
-	if numPlayers < 2 {
-		return fmt.Errorf("invalid game: need at least 2 players, got %d", numPlayers)
-	}
-
-	// Ensure all players have a starting score and capture snapshot ratings.
-	ratings := make([]float64, numPlayers)
-	for i := 0; i < numPlayers; i++ {
-		name := game[i]
-		if _, exists := scores[name]; !exists {
-			scores[name] = defaultStartingScore
-		}
-		ratings[i] = float64(scores[name])
-	}
-
-	// Accumulate Elo deltas based on all pairwise outcomes from the snapshot.
-	deltas := make([]float64, numPlayers)
-	K := float64(elo.K)
-	D := float64(elo.D)
-	for i := range numPlayers {
-		for j := i + 1; j < numPlayers; j++ {
-			// Player at index i placed higher than player at index j, so i wins vs j.
-			RA := ratings[i]
-			RB := ratings[j]
-
-			// Expected score of A vs B
-			EA := 1.0 / (1.0 + math.Pow(10, (RB-RA)/D))
-
-			// Single-game deltas
-			deltaA := K * (1.0 - EA) // A wins
-			deltaB := -deltaA        // B loses
-
-			// Apply deltas
-			deltas[i] += deltaA
-			deltas[j] += deltaB
-		}
-	}
-
-	// Apply accumulated deltas.
-	for i := range numPlayers {
-		name := game[i]
-		newRating := int(math.Round(ratings[i] + deltas[i]))
-		scores[name] = newRating
+// loadScores replays every game from the chosen source and returns the final ratings.
+func loadScores(cfg source.Config) (map[string]int, error) {
+	games, err := loadGames(cfg)
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	return analyzer.RateGames(analyzer.InitializeElo(), games, nil)
 }
 
 func calculateFinalScores(scores map[string]int) []finalScore {
@@ -174,5 +143,3 @@ func calculateFinalScores(scores map[string]int) []finalScore {
 
 	return finalScores
 }
-
-// displayScores was previously used for console output; removed to avoid unused warnings.
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/dylanlott/guildmaster/internal/analyzer"
+	"github.com/dylanlott/guildmaster/internal/source"
+)
+
+// runProject simulates the rest of the season and prints each player's chance
+// to finish first and within the top places.
+func runProject(args []string) error {
+	fs := flag.NewFlagSet("project", flag.ExitOnError)
+	src := source.Config{Kind: "csv", Path: "./mtgscores.csv"}
+	src.RegisterFlags(fs)
+	schedule := fs.String("schedule", "", "CSV of remaining games, one pod per row (overrides -games)")
+	games := fs.Int("games", 10, "remaining games per player when no schedule is given")
+	pod := fs.Int("pod", 4, "players per game when no schedule is given")
+	sims := fs.Int("sims", 10000, "number of seasons to simulate")
+	seed := fs.Uint64("seed", 1, "random seed for reproducible projections")
+	top := fs.Int("top", 3, "report the chance to finish within this many places")
+	fs.Parse(args)
+
+	elo := analyzer.InitializeElo()
+	scores, err := loadScores(src)
+	if err != nil {
+		return fmt.Errorf("error processing scores: %w", err)
+	}
+
+	cfg := analyzer.ProjectionConfig{
+		GamesPerPlayer: *games,
+		PodSize:        *pod,
+		Simulations:    *sims,
+		Seed:           *seed,
+	}
+	if *schedule != "" {
+		pods, err := analyzer.ReadSchedule(*schedule)
+		if err != nil {
+			return err
+		}
+		cfg.Schedule = pods
+	}
+
+	projections, err := analyzer.Project(elo, scores, cfg)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Player\tElo\tP(#1)\tP(top %d)\tAvg rank\tAvg Elo\n", *top)
+	for _, p := range projections {
+		fmt.Fprintf(w, "%s\t%d\t%.1f%%\t%.1f%%\t%.2f\t%.0f\n",
+			p.Player, p.Rating, 100*p.Chance(1), 100*p.TopChance(*top), p.MeanRank, p.MeanRating)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/dylanlott/guildmaster/internal/analyzer"
+	"github.com/dylanlott/guildmaster/internal/source"
+)
+
+// runRecord appends a game to the log. Players are given in finishing order,
+// winner first.
+func runRecord(args []string) error {
+	fs := flag.NewFlagSet("record", flag.ExitOnError)
+	src := source.Config{Kind: "csv", Path: "./mtgscores.csv"}
+	src.RegisterFlags(fs)
+	date := fs.String("date", "", "date of the game (default today)")
+	notes := fs.String("notes", "", "notes about the game")
+	turns := fs.String("turns", "", "number of turns the game lasted")
+	turnOrder := fs.String("turn-order", "", "comma separated players in seat order")
+	elims := fs.String("eliminations", "", "comma separated Killer>Victim eliminations")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: guildmaster record [flags] winner second [third ...]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	g := analyzer.Game{Date: *date, Rankings: fs.Args(), Notes: *notes, TurnOrder: analyzer.ParseTurnOrder(*turnOrder)}
+	if len(g.Rankings) < 2 {
+		return fmt.Errorf("need at least 2 players in finishing order")
+	}
+	if g.Date == "" {
+		g.Date = time.Now().Format("1/2/2006")
+	}
+	var err error
+	if g.Turns, err = analyzer.ParseTurns(*turns); err != nil {
+		return err
+	}
+	if g.Eliminations, err = analyzer.ParseEliminations(*elims); err != nil {
+		return err
+	}
+
+	s, err := src.Open()
+	if err != nil {
+		return err
+	}
+	w, ok := s.(analyzer.GameWriter)
+	if !ok {
+		return fmt.Errorf("source %q can't record games", src.Kind)
+	}
+	if err := w.AppendGame(context.Background(), g); err != nil {
+		return err
+	}
+	fmt.Printf("recorded game on %s: %v\n", g.Date, g.Rankings)
+	return nil
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/dylanlott/guildmaster/internal/site"
+	"github.com/dylanlott/guildmaster/internal/source"
+)
+
+// runSite renders the league as a static website that can be published
+// without running the server.
+func runSite(args []string) error {
+	fs := flag.NewFlagSet("site", flag.ExitOnError)
+	src := source.Config{Kind: "csv", Path: "./mtgscores.csv"}
+	src.RegisterFlags(fs)
+	out := fs.String("out", "public", "directory to write the site to")
+	fs.Parse(args)
+
+	games, err := loadGames(src)
+	if err != nil {
+		return err
+	}
+	if err := site.Build(*out, games); err != nil {
+		return err
+	}
+	fmt.Printf("wrote %d games to %s\n", len(games), *out)
+	return nil
+}
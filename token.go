@@ -0,0 +1,86 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/dylanlott/guildmaster/internal/auth"
+)
+
+// tokensFlag adds the -tokens flag naming the local tokens file.
+func tokensFlag(fs *flag.FlagSet) *string {
+	def := os.Getenv("GUILDMASTER_TOKENS")
+	if def == "" {
+		def = "tokens.json"
+	}
+	return fs.String("tokens", def, "file the server's API tokens are kept in (env GUILDMASTER_TOKENS)")
+}
+
+// runToken mints, lists and revokes the API tokens in the tokens file the
+// server reads with -tokens.
+func runToken(args []string) error {
+	usage := "usage: guildmaster token mint|list|revoke [flags]"
+	if len(args) == 0 {
+		return fmt.Errorf("%s", usage)
+	}
+	fs := flag.NewFlagSet("token "+args[0], flag.ExitOnError)
+	path := tokensFlag(fs)
+	switch args[0] {
+	case "mint":
+		name := fs.String("name", "", "who or what the token is for")
+		role := fs.String("role", string(auth.Scorekeeper), "what the token may do: "+auth.Roles)
+		fs.Parse(args[1:])
+		if *name == "" {
+			return fmt.Errorf("missing -name")
+		}
+		r, err := auth.ParseRole(*role)
+		if err != nil {
+			return err
+		}
+		tokens, err := auth.Open(*path)
+		if err != nil {
+			return err
+		}
+		secret, tok, err := tokens.Mint(*name, r)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "minted %s token %s for %s; it is shown only once:\n", tok.Role, tok.ID, tok.Name)
+		fmt.Println(secret)
+		return nil
+	case "list":
+		fs.Parse(args[1:])
+		tokens, err := auth.Open(*path)
+		if err != nil {
+			return err
+		}
+		list, err := tokens.List()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tName\tRole\tCreated")
+		for _, t := range list {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", t.ID, t.Name, t.Role, t.CreatedAt.Format("2006-01-02 15:04"))
+		}
+		return w.Flush()
+	case "revoke":
+		fs.Parse(args[1:])
+		if fs.NArg() != 1 {
+			return fmt.Errorf("usage: guildmaster token revoke [flags] token-id")
+		}
+		tokens, err := auth.Open(*path)
+		if err != nil {
+			return err
+		}
+		if err := tokens.Revoke(fs.Arg(0)); err != nil {
+			return err
+		}
+		fmt.Printf("revoked token %s\n", fs.Arg(0))
+		return nil
+	default:
+		return fmt.Errorf("unknown token command %q; %s", args[0], usage)
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
@@ -29,6 +30,10 @@ var (
 			MarginBottom(1).
 			Align(lipgloss.Center)
 
+	statusStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("220")).
+			Bold(true)
+
 	footerStyle = lipgloss.NewStyle().
 			Foreground(colors.background).
 			Background(colors.secondary).
@@ -41,10 +46,38 @@ var (
 type Model struct {
 	table  table.Model
 	scores []finalScore
+
+	// set in watch mode: reload reads the rankings again whenever changes fires
+	reload  func() ([]finalScore, error)
+	changes <-chan struct{}
+	status  string
+}
+
+// reloadedMsg carries the rankings read after the game log changed.
+type reloadedMsg struct {
+	scores []finalScore
+	err    error
+	at     time.Time
+}
+
+// waitForChange blocks until the game log changes and then reloads it.
+func (m Model) waitForChange() tea.Cmd {
+	return func() tea.Msg {
+		if _, ok := <-m.changes; !ok {
+			return nil
+		}
+		scores, err := m.reload()
+		return reloadedMsg{scores: scores, err: err, at: time.Now()}
+	}
 }
 
 // Init initializes the model
-func (m Model) Init() tea.Cmd { return nil }
+func (m Model) Init() tea.Cmd {
+	if m.changes != nil {
+		return m.waitForChange()
+	}
+	return nil
+}
 
 // Update handles key events
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -55,6 +88,18 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "q", "ctrl+c":
 			return m, tea.Quit
 		}
+	case reloadedMsg:
+		if msg.err != nil {
+			// keep showing the last good rankings while the file is mid-edit
+			m.status = fmt.Sprintf("Reload failed at %s: %v", msg.at.Format("15:04:05"), msg.err)
+			return m, m.waitForChange()
+		}
+		diff := diffRankings(m.scores, msg.scores)
+		m.scores = msg.scores
+		m.table.SetRows(rankingRows(m.scores, diff, true))
+		m.table.SetHeight(min(len(m.scores), 15))
+		m.status = fmt.Sprintf("Reloaded at %s: %d players changed", msg.at.Format("15:04:05"), len(diff))
+		return m, m.waitForChange()
 	}
 
 	m.table, cmd = m.table.Update(msg)
@@ -64,27 +109,50 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 // View renders the TUI
 func (m Model) View() string {
 	title := titleStyle.Render("Player Rankings")
-	footer := footerStyle.Render("Press q or Ctrl+C to quit")
+	help := "Press q or Ctrl+C to quit"
+	if m.changes != nil {
+		help = "Watching for changes — " + help
+	}
+	footer := footerStyle.Render(help)
 	tableView := m.table.View()
+	if m.status != "" {
+		footer = statusStyle.Render(m.status) + "\n" + footer
+	}
 	return fmt.Sprintf("%s\n%s\n%s", title, tableView, footer)
 }
 
-// DisplayRankingsTUI displays the rankings in a Bubbletea TUI
-func DisplayRankingsTUI(finalScores []finalScore) error {
+// rankingRows builds the table rows, with a column showing each player's
+// change since the last reload when watching.
+func rankingRows(finalScores []finalScore, diff map[string]rankingChange, watching bool) []table.Row {
+	rows := []table.Row{}
+	for i, score := range finalScores {
+		rank := strconv.Itoa(i + 1)
+		elo := strconv.Itoa(score.eloScore)
+		row := table.Row{rank, score.player, elo}
+		if watching {
+			row = append(row, diff[score.player].String())
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// DisplayRankingsTUI displays the rankings in a Bubbletea TUI. When changes is
+// non-nil the rankings are reloaded with reload every time it fires.
+func DisplayRankingsTUI(finalScores []finalScore, reload func() ([]finalScore, error), changes <-chan struct{}) error {
 	// Define table columns
 	columns := []table.Column{
 		{Title: "Rank", Width: 6},
 		{Title: "Player", Width: 30},
 		{Title: "ELO Score", Width: 10},
 	}
+	watching := changes != nil
+	if watching {
+		columns = append(columns, table.Column{Title: "Change", Width: 12})
+	}
 
 	// Prepare rows
-	rows := []table.Row{}
-	for i, score := range finalScores {
-		rank := strconv.Itoa(i + 1)
-		elo := strconv.Itoa(score.eloScore)
-		rows = append(rows, table.Row{rank, score.player, elo})
-	}
+	rows := rankingRows(finalScores, nil, watching)
 
 	// Create table
 	t := table.New(
@@ -108,7 +176,7 @@ func DisplayRankingsTUI(finalScores []finalScore) error {
 	t.SetStyles(s)
 
 	// Create model and run program
-	m := Model{table: t, scores: finalScores}
+	m := Model{table: t, scores: finalScores, reload: reload, changes: changes}
 	p := tea.NewProgram(m)
 	if _, err := p.Run(); err != nil {
 		return fmt.Errorf("error running TUI: %v", err)
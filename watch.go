@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/dylanlott/guildmaster/internal/source"
+	"github.com/dylanlott/guildmaster/internal/watch"
+)
+
+// rankingChange is how a player's place moved between two reloads.
+type rankingChange struct {
+	rank  int // places gained (positive) or lost
+	score int
+	isNew bool
+}
+
+// String renders the change as e.g. "▲2 +15", or "" when nothing changed.
+func (c rankingChange) String() string {
+	if c.isNew {
+		return "new"
+	}
+	s := ""
+	switch {
+	case c.rank > 0:
+		s = fmt.Sprintf("▲%d", c.rank)
+	case c.rank < 0:
+		s = fmt.Sprintf("▼%d", -c.rank)
+	}
+	if c.score != 0 {
+		if s != "" {
+			s += " "
+		}
+		s += fmt.Sprintf("%+d", c.score)
+	}
+	return s
+}
+
+// diffRankings reports the players whose rating or rank changed from prev to cur.
+func diffRankings(prev, cur []finalScore) map[string]rankingChange {
+	type place struct{ rank, score int }
+	before := make(map[string]place, len(prev))
+	for i, s := range prev {
+		before[s.player] = place{i + 1, s.eloScore}
+	}
+	changes := make(map[string]rankingChange)
+	for i, s := range cur {
+		p, ok := before[s.player]
+		switch {
+		case !ok:
+			changes[s.player] = rankingChange{isNew: true}
+		case p.rank != i+1 || p.score != s.eloScore:
+			changes[s.player] = rankingChange{rank: p.rank - (i + 1), score: s.eloScore - p.score}
+		}
+	}
+	return changes
+}
+
+// reloadScores reads the rankings again from cfg.
+func reloadScores(cfg source.Config) ([]finalScore, error) {
+	scores, err := loadScores(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return calculateFinalScores(scores), nil
+}
+
+// watchedPath returns the file to watch for cfg, which must be a file source.
+func watchedPath(cfg source.Config) (string, error) {
+	switch cfg.Kind {
+	case "csv", "jsonl":
+		return cfg.Path, nil
+	default:
+		return "", fmt.Errorf("-watch needs a file source (csv or jsonl), not %q", cfg.Kind)
+	}
+}
+
+// watchRankings prints the rankings and redraws them whenever the game log
+// changes, marking players whose rating or rank moved, until interrupted.
+func watchRankings(cfg source.Config, w io.Writer) error {
+	path, err := watchedPath(cfg)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	// keep per-game warnings from scrolling the rankings away
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	current, err := reloadScores(cfg)
+	if err != nil {
+		return err
+	}
+	printRankings(w, current, nil, "")
+	changes := watch.Changes(ctx, path, watch.DefaultInterval)
+	for range changes {
+		next, err := reloadScores(cfg)
+		status := fmt.Sprintf("reloaded %s at %s", path, time.Now().Format("15:04:05"))
+		if err != nil {
+			// keep showing the last good rankings while the file is mid-edit
+			printRankings(w, current, nil, fmt.Sprintf("reload failed at %s: %v", time.Now().Format("15:04:05"), err))
+			continue
+		}
+		diff := diffRankings(current, next)
+		current = next
+		printRankings(w, current, diff, fmt.Sprintf("%s, %d players changed", status, len(diff)))
+	}
+	return nil
+}
+
+// printRankings clears the terminal and prints the rankings with their changes.
+func printRankings(w io.Writer, scores []finalScore, diff map[string]rankingChange, status string) {
+	fmt.Fprint(w, "\033[H\033[2J")
+	for i, v := range scores {
+		line := fmt.Sprintf("%d --- %s --- %d", i+1, v.player, v.eloScore)
+		if c, ok := diff[v.player]; ok {
+			line = fmt.Sprintf("\033[1;33m%s  (%s)\033[0m", line, c)
+		}
+		fmt.Fprintln(w, line)
+	}
+	if status != "" {
+		fmt.Fprintf(w, "\n%s\n", status)
+	}
+	fmt.Fprintln(w, "watching for changes, Ctrl+C to stop")
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestDiffRankings(t *testing.T) {
+	prev := []finalScore{{"A", 1540}, {"B", 1510}, {"C", 1490}, {"D", 1460}}
+	for _, tc := range []struct {
+		name string
+		cur  []finalScore
+		want map[string]string
+	}{
+		{"unchanged", prev, map[string]string{}},
+		{"new player", []finalScore{{"A", 1540}, {"B", 1510}, {"E", 1500}, {"C", 1490}, {"D", 1460}},
+			map[string]string{"E": "new", "C": "▼1", "D": "▼1"}},
+		{"rank up and down", []finalScore{{"B", 1530}, {"A", 1522}, {"C", 1490}, {"D", 1460}},
+			map[string]string{"B": "▲1 +20", "A": "▼1 -18"}},
+		{"score only", []finalScore{{"A", 1556}, {"B", 1510}, {"C", 1490}, {"D", 1444}},
+			map[string]string{"A": "+16", "D": "-16"}},
+		{"rank only", []finalScore{{"A", 1540}, {"C", 1490}, {"D", 1460}},
+			map[string]string{"C": "▲1", "D": "▲1"}},
+		{"jump", []finalScore{{"D", 1560}, {"A", 1540}, {"B", 1510}, {"C", 1490}},
+			map[string]string{"D": "▲3 +100", "A": "▼1", "B": "▼1", "C": "▼1"}},
+	} {
+		got := make(map[string]string)
+		for player, c := range diffRankings(prev, tc.cur) {
+			got[player] = c.String()
+		}
+		if !maps.Equal(got, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	if s := (rankingChange{}).String(); s != "" {
+		t.Errorf("no change renders as %q, want nothing", s)
+	}
+}